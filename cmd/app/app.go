@@ -1,58 +1,232 @@
 package app
 
 import (
+	"editGo/config"
 	"editGo/data"
 	"editGo/editor"
+	"editGo/syntax"
 	"editGo/ui"
+	"errors"
+	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"io/fs"
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 	"time"
 )
 
 type Model struct {
-	Buffer        *editor.TextBuffer
-	Cursor        *editor.CursorPointer
-	File          *data.FileManager
-	UndoStack     *editor.UndoManager
-	AutoSaver     *data.AutoSave
+	*Document                 // the active buffer
+	Docs          []*Document // every open buffer, in tab order
+	Display       ui.RenderOptions
+	Width         int // terminal size, from the last tea.WindowSizeMsg
+	Height        int
 	StatusMessage string
+	Config        config.Config
+	UndoPanel     bool // whether the undo tree panel is shown
+	UndoSelected  int  // branch highlighted in the undo tree panel
+
+	Command commandLine  // the ":" command line, opened with Ctrl+E
+	SaveAs  saveAsPrompt // asks where to save an unnamed buffer
+
+	QuitDialog quitDialog // asks about unsaved changes before quitting
+	Picker     filePicker // chooses a file to open, with Ctrl+O
 }
 
-func NewModel(filePath string) Model {
-	var file *data.FileManager
-	var err error
+// NewModel opens each of paths in its own buffer, the first one active, or
+// a single empty buffer when there are none.
+func NewModel(paths ...string) Model {
+	cfg, err := config.Load()
+	var status string
+	if err != nil {
+		status = "Error: config: " + err.Error()
+	}
+	if _, err := data.ParsePolicy(cfg.Autosave); err != nil {
+		status = "Error: config: " + err.Error()
+	}
 
-	if filePath != "" {
-		file, err = data.NewFile(filePath)
+	display := ui.RenderOptions{
+		TabWidth: cfg.TabWidth,
+		Wrap:     cfg.SoftWrap,
+		WordWrap: cfg.WordWrap,
 	}
-	if err != nil {
-		file, _ = data.NewEmptyFile(filePath)
+	if numbers, err := ui.ParseLineNumbers(cfg.LineNumbers); err != nil {
+		status = "Error: config: " + err.Error()
+	} else {
+		display.Gutter.Numbers = numbers
+	}
+	if err := setTheme(cfg.Theme); err != nil {
+		status = "Error: config: " + err.Error()
+	}
+
+	m := Model{
+		Display: display,
+		Width:   80, // until the first WindowSizeMsg
+		Height:  24,
+		Config:  cfg,
+
+		StatusMessage: status,
+	}
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	for _, path := range paths {
+		if m.findDocument(path) >= 0 {
+			continue
+		}
+		file, fileStatus := openFile(path)
+		if fileStatus != "" && !strings.HasPrefix(m.StatusMessage, "Error:") {
+			m.StatusMessage = fileStatus
+		}
+		m.Docs = append(m.Docs, m.newDocument(file))
 	}
+	m.Document = m.Docs[0]
+	return m
+}
 
-	buffer := file.Buffer
-	cursor := editor.NewCursor(0, 0)
-	undo := editor.NewUndoManager()
-	auto := data.NewAutoSave(file, 5*time.Second)
-	auto.Start()
+// openFile loads filePath, falling back to an empty buffer. A missing file
+// is a new file; any other read error is reported in the returned status
+// and the buffer is left unnamed, so saving it can't clobber the file we
+// failed to read.
+func openFile(filePath string) (*data.FileManager, string) {
+	if filePath == "" {
+		file, _ := data.NewEmptyFile("")
+		return file, ""
+	}
+	file, err := data.NewFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		file, _ = data.NewEmptyFile(filePath)
+		return file, "New file: " + filePath
+	}
+	if err != nil {
+		file, _ = data.NewEmptyFile("")
+		return file, "Error: could not open " + filePath + ": " + err.Error()
+	}
+	return file, ""
+}
 
-	return Model{
-		Buffer:    buffer,
-		Cursor:    cursor,
-		File:      file,
-		UndoStack: undo,
-		AutoSaver: auto,
+// chromeLines is the rows taken by the status bar, help bar and message
+// line, and by the tab bar while there is more than one buffer.
+func (m Model) chromeLines() int {
+	if len(m.Docs) > 1 {
+		return 4
 	}
+	return 3
 }
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	cmds := []tea.Cmd{checkDiskLater()}
+	for _, d := range m.Docs {
+		cmds = append(cmds, waitForAutoSave(d))
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	next := model.(Model)
+	next.layout()
+	next.follow()
+	return next, cmd
+}
+
+// follow scrolls the viewport to keep the cursor in view.
+func (m *Model) follow() {
+	if !m.Display.Wrap {
+		m.Viewport.Follow(m.cursorColumn(), m.Cursor.Y)
+		return
+	}
+	wrap := m.wrap()
+	row, _ := wrap.Locate(m.Buffer.GetLine(m.Cursor.Y), m.Cursor.X)
+	m.Viewport.FollowWrapped(m.Cursor.Y, row, m.wrappedRows(wrap))
+}
+
+// wrappedRows returns how many display rows each line takes with wrap.
+func (m *Model) wrappedRows(wrap editor.Wrap) func(line int) int {
+	return func(line int) int {
+		return len(wrap.Segments(m.Buffer.GetLine(line)))
+	}
+}
+
+// page moves the cursor and the window a screen up (dir -1) or down
+// (dir 1). With soft wrap a screen is display rows, not buffer lines.
+func (m *Model) page(dir int) {
+	n := dir * m.Viewport.Height
+	if !m.Display.Wrap {
+		m.Cursor.MoveLines(m.Buffer, n)
+		m.Viewport.Scroll(n, m.Buffer.LineCount())
+		return
+	}
+	wrap := m.wrap()
+	for range m.Viewport.Height {
+		if dir < 0 {
+			m.Cursor.MoveDisplayUp(m.Buffer, wrap)
+		} else {
+			m.Cursor.MoveDisplayDown(m.Buffer, wrap)
+		}
+	}
+	m.Viewport.ScrollWrapped(n, m.Buffer.LineCount(), m.wrappedRows(wrap))
+}
+
+// cursorColumn is the screen column of the cursor within its line.
+func (m Model) cursorColumn() int {
+	return editor.DisplayColumn(m.Buffer.GetLine(m.Cursor.Y), m.Cursor.X, m.Display.TabWidth)
+}
+
+func (m *Model) wrap() editor.Wrap {
+	return m.Display.WrapAt(m.Viewport.Width)
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.Width, m.Height = msg.Width, msg.Height
+	case diskCheckMsg:
+		m.checkDisk()
+		return m, checkDiskLater()
+	case autoSaveMsg:
+		d := msg.doc
+		if msg.result.Err != nil {
+			m.saveError(d, msg.result.Err)
+		} else {
+			m.StatusMessage = "Autosaved " + msg.result.Time.Format("15:04:05")
+			if !d.Buffer.IsDirty() { // history still matches what was written
+				d.File.SaveHistory(msg.result.Hash)
+			}
+		}
+		if msg.saver != d.AutoSaver {
+			return m, nil // replaced by a newer autosave with its own waiter
+		}
+		return m, waitForAutoSave(d)
+	case tea.BlurMsg:
+		for _, d := range m.Docs {
+			d.AutoSaver.NotifyFocusLost()
+		}
 	case tea.KeyMsg:
+		if m.Recovery != nil {
+			return m.updateRecovery(msg)
+		}
+		if m.ExternalChange {
+			return m.updateExternal(msg)
+		}
+		if m.QuitDialog.open {
+			return m.updateQuitDialog(msg)
+		}
+		if m.Picker.open {
+			return m.updatePicker(msg)
+		}
+		if m.Command.open {
+			return m.updateCommandLine(msg)
+		}
+		if m.SaveAs.open {
+			return m.updateSaveAs(msg)
+		}
+		if m.UndoPanel {
+			return m.updateUndoPanel(msg)
+		}
 		switch {
 		case (len(msg.Runes) == 1 && msg.Type == tea.KeyRunes) || msg.String() == " ":
 			var r rune
@@ -61,66 +235,229 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else {
 				r = ' ' // fallback for space
 			}
-			m.UndoStack.Push(m.Buffer)
-			m.Buffer.InsertRune(m.Cursor.Y, m.Cursor.X, r)
-			m.Cursor.MoveRight(m.Buffer)
+			m.apply(editor.EditAction{
+				Line: m.Cursor.Y, Col: m.Cursor.X, Text: []rune{r}, Action: editor.ActionInsert,
+			})
+		case msg.Type == tea.KeyTab:
+			m.apply(editor.EditAction{
+				Line: m.Cursor.Y, Col: m.Cursor.X, Text: []rune{'\t'}, Action: editor.ActionInsert,
+			})
 		case msg.Type == tea.KeyBackspace:
 			if m.Cursor.X == 0 && m.Cursor.Y == 0 {
 				break // at top-left, nothing to delete
 			}
 
-			m.UndoStack.Push(m.Buffer)
-
 			if m.Cursor.X > 0 {
-				m.Buffer.DeleteRune(m.Cursor.Y, m.Cursor.X, 0)
-				m.Cursor.MoveLeft(m.Buffer)
+				deleted := m.Buffer.GetLine(m.Cursor.Y)[m.Cursor.X-1]
+				m.apply(editor.EditAction{
+					Line: m.Cursor.Y, Col: m.Cursor.X - 1, Text: []rune{deleted}, Action: editor.ActionDelete,
+				})
 			} else if m.Cursor.Y > 0 {
 				// Merge with previous line
 				prevLineLen := len(m.Buffer.GetLine(m.Cursor.Y - 1))
-				m.Buffer.MergeLine(m.Cursor.Y - 1)
-				m.Cursor.Y--
-				m.Cursor.X = prevLineLen
+				m.apply(editor.EditAction{
+					Line: m.Cursor.Y - 1, Col: prevLineLen, Text: []rune{'\n'}, Action: editor.ActionDelete,
+				})
 			}
 		case msg.Type == tea.KeyEnter:
-			m.UndoStack.Push(m.Buffer)
-			m.Buffer.InsertNewLine(m.Cursor.Y, m.Cursor.X)
-			m.Cursor.Y++
-			m.Cursor.X = 0
+			m.apply(editor.EditAction{
+				Line: m.Cursor.Y, Col: m.Cursor.X, Text: []rune{'\n'}, Action: editor.ActionInsert,
+			})
 		case msg.Type == tea.KeyCtrlQ, msg.Type == tea.KeyCtrlC:
-			m.AutoSaver.Stop()
-			clearTerminal()
-			return m, tea.Quit
+			return m, m.requestQuit()
+		case msg.Type == tea.KeyCtrlPgUp:
+			m.cycleBuffer(-1)
+		case msg.Type == tea.KeyCtrlPgDown:
+			m.cycleBuffer(1)
+		case msg.Type == tea.KeyCtrlW:
+			m.UndoStack.Boundary()
+			return m, m.requestClose()
+		case msg.Type == tea.KeyCtrlE:
+			m.UndoStack.Boundary()
+			m.Command.start()
+		case msg.Type == tea.KeyCtrlO:
+			m.UndoStack.Boundary()
+			m.openPicker()
 		case msg.Type == tea.KeyUp:
-			m.Cursor.MoveUp(m.Buffer)
+			m.UndoStack.Boundary()
+			if m.Display.Wrap {
+				m.Cursor.MoveDisplayUp(m.Buffer, m.wrap())
+			} else {
+				m.Cursor.MoveUp(m.Buffer)
+			}
 		case msg.Type == tea.KeyDown:
-			m.Cursor.MoveDown(m.Buffer)
+			m.UndoStack.Boundary()
+			if m.Display.Wrap {
+				m.Cursor.MoveDisplayDown(m.Buffer, m.wrap())
+			} else {
+				m.Cursor.MoveDown(m.Buffer)
+			}
 		case msg.Type == tea.KeyLeft:
+			m.UndoStack.Boundary()
 			m.Cursor.MoveLeft(m.Buffer)
 		case msg.Type == tea.KeyRight:
+			m.UndoStack.Boundary()
 			m.Cursor.MoveRight(m.Buffer)
+		case msg.Type == tea.KeyPgUp:
+			m.UndoStack.Boundary()
+			m.page(-1)
+		case msg.Type == tea.KeyPgDown:
+			m.UndoStack.Boundary()
+			m.page(1)
+		case msg.Type == tea.KeyHome:
+			m.UndoStack.Boundary()
+			m.Cursor.MoveLineStart()
+		case msg.Type == tea.KeyEnd:
+			m.UndoStack.Boundary()
+			m.Cursor.MoveLineEnd(m.Buffer)
+		case msg.Type == tea.KeyCtrlHome:
+			m.UndoStack.Boundary()
+			m.Cursor.MoveBufferStart()
+		case msg.Type == tea.KeyCtrlEnd:
+			m.UndoStack.Boundary()
+			m.Cursor.MoveBufferEnd(m.Buffer)
 		case msg.Type == tea.KeyCtrlZ:
-			m.UndoStack.Undo(m.Buffer)
-			m.Cursor.Clamp(m.Buffer)
+			m.restoreCursor(m.UndoStack.Undo(m.Buffer))
 		case msg.Type == tea.KeyCtrlY:
-			m.UndoStack.Redo(m.Buffer)
-			m.Cursor.Clamp(m.Buffer)
+			m.restoreCursor(m.UndoStack.Redo(m.Buffer))
+		case msg.Type == tea.KeyF2:
+			m.UndoStack.Boundary()
+			m.openUndoPanel()
+		case msg.Type == tea.KeyF3:
+			m.Display.Gutter.Numbers = m.Display.Gutter.Numbers.Next()
+			m.StatusMessage = "Line numbers: " + m.Display.Gutter.Numbers.String()
+		case msg.Type == tea.KeyF4:
+			m.Display.Wrap = !m.Display.Wrap
+			m.StatusMessage = "Soft wrap: " + onOff(m.Display.Wrap)
+		case msg.Type == tea.KeyF5:
+			m.cycleLineEnding()
+		case msg.Type == tea.KeyF6:
+			m.cycleTheme()
 		case msg.Type == tea.KeyCtrlS:
+			m.UndoStack.Boundary()
 			if m.File.FilePath == "" {
-				// Optional: add SaveAs prompt later
-				return m, nil
-			}
-
-			err := m.File.Save()
-			if err != nil {
-				m.StatusMessage = "Error: " + err.Error()
+				m.SaveAs.start()
 			} else {
-				m.StatusMessage = "Saved to: " + m.File.FilePath
+				m.save()
 			}
 		}
 	}
 	return m, nil
 }
 
+// apply makes an edit at the cursor and tells autosave and the
+// highlighter about it.
+func (m *Model) apply(action editor.EditAction) {
+	lineCount := m.Buffer.LineCount()
+	m.UndoStack.Apply(m.Buffer, m.Cursor, action)
+	m.Highlighter.Invalidate(action.Line, m.Buffer.LineCount()-lineCount)
+	m.AutoSaver.NotifyEdit()
+}
+
+// save writes the buffer to its file, reporting the outcome in the status
+// line.
+func (m *Model) save() bool {
+	if err := m.File.Save(); err != nil {
+		m.saveError(m.Document, err)
+		return false
+	}
+	m.StatusMessage = "Saved to: " + m.File.FilePath
+	return true
+}
+
+// saveAs writes the buffer to path, which becomes the file being edited.
+// An existing file other than the current one is only overwritten when
+// forced. An unnamed buffer gets a fresh autosave for its new file, and
+// the returned command waits for its results.
+func (m *Model) saveAs(path string, force bool) (tea.Cmd, error) {
+	if path != m.File.FilePath && !force {
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%s exists (add ! to overwrite)", path)
+		}
+	}
+	unnamed := m.File.FilePath == ""
+	if err := m.File.SaveAs(path); err != nil {
+		return nil, err
+	}
+	m.Highlighter = syntax.NewHighlighter(syntax.ForFile(path))
+	m.StatusMessage = "Saved to: " + path
+	if !unnamed {
+		return nil, nil
+	}
+	m.AutoSaver.Stop()
+	m.startAutoSave(m.Config.Autosave)
+	return waitForAutoSave(m.Document), nil
+}
+
+// quit stops autosave and ends the program. Whatever is left unsaved was
+// discarded, so the swap files go too.
+func (m *Model) quit() tea.Cmd {
+	for _, d := range m.Docs {
+		d.discard()
+	}
+	clearTerminal()
+	return tea.Quit
+}
+
+// cycleLineEnding converts the file to the next of LF, CRLF and CR.
+func (m *Model) cycleLineEnding() {
+	next := data.LF
+	switch m.File.LineEnding {
+	case data.LF:
+		next = data.CRLF
+	case data.CRLF:
+		next = data.CR
+	}
+	if err := m.setLineEnding(next); err != nil {
+		m.StatusMessage = "Error: " + err.Error()
+		return
+	}
+	m.StatusMessage = "Line endings: " + next.String()
+}
+
+func (m *Model) setLineEnding(le data.LineEnding) error {
+	if err := m.File.ConvertLineEnding(le, m.Cursor); err != nil {
+		return err
+	}
+	m.Highlighter.Reset()
+	m.AutoSaver.NotifyEdit()
+	return nil
+}
+
+// setTheme restyles the UI with the user's or the built-in theme called
+// name.
+func setTheme(name string) error {
+	theme, err := ui.FindTheme(name, config.ThemeDir())
+	if err != nil {
+		return err
+	}
+	ui.SetTheme(theme)
+	return nil
+}
+
+// cycleTheme switches to the next theme in name order.
+func (m *Model) cycleTheme() {
+	names := ui.ThemeNames(config.ThemeDir())
+	next := names[0]
+	for i, name := range names {
+		if name == ui.CurrentTheme() && i+1 < len(names) {
+			next = names[i+1]
+		}
+	}
+	if err := setTheme(next); err != nil {
+		m.StatusMessage = "Error: " + err.Error()
+		return
+	}
+	m.StatusMessage = "Theme: " + next
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
 func clearTerminal() {
 	var cmd *exec.Cmd
 
@@ -136,9 +473,79 @@ func clearTerminal() {
 	_ = cmd.Run()
 }
 
+// layout fits the viewport into the space the bars, the gutter and the
+// undo panel leave free.
+func (m *Model) layout() {
+	width := m.Width - m.Display.Gutter.Width(m.Buffer.LineCount())
+	if m.UndoPanel {
+		width -= lipgloss.Width(m.renderUndoPanel())
+	}
+	m.Viewport.Resize(width, m.Height-m.chromeLines())
+}
+
+func (m Model) renderUndoPanel() string {
+	return ui.RenderUndoTree(m.UndoStack.Branches(), m.UndoStack.CurrentSeq(), m.UndoSelected, time.Now(), m.Height-m.chromeLines())
+}
+
+// renderMessageLine shows the command line or a pending prompt, or else
+// the status message.
+func (m Model) renderMessageLine() string {
+	if m.Command.open {
+		return ui.RenderInput(":", m.Command.input.text, m.Command.input.pos, m.Width)
+	}
+	if m.SaveAs.open {
+		if m.SaveAs.overwrite != "" {
+			return ui.RenderPrompt(m.overwritePrompt(), m.Width)
+		}
+		return ui.RenderInput("Save as: ", m.SaveAs.input.text, m.SaveAs.input.pos, m.Width)
+	}
+	if m.Recovery != nil {
+		return ui.RenderPrompt(m.recoveryPrompt(), m.Width)
+	}
+	if m.ExternalChange {
+		return ui.RenderPrompt(m.externalPrompt(), m.Width)
+	}
+	return ui.RenderStatusMessage(m.StatusMessage, m.Width)
+}
+
+func (m Model) renderHelpBar() string {
+	if m.QuitDialog.open {
+		return ui.RenderHint(quitHint(), m.Width)
+	}
+	if m.Picker.open {
+		return ui.RenderHint(pickerHint(), m.Width)
+	}
+	if m.Command.open {
+		return ui.RenderHint(m.commandHint(), m.Width)
+	}
+	if m.SaveAs.open {
+		return ui.RenderHint(m.saveAsHint(), m.Width)
+	}
+	return ui.RenderHelpBar(m.Width)
+}
+
 func (m Model) View() string {
-	return ui.RenderStatusBar(m.File.FilePath, m.Buffer.IsDirty(), m.Cursor.X, m.Cursor.Y) + "\n" +
-		ui.RenderBuffer(m.Buffer.Lines, m.Cursor.X, m.Cursor.Y) + "\n" +
-		ui.RenderHelpBar() + "\n" +
-		ui.RenderStatusMessage(m.StatusMessage)
+	display := m.Display
+	display.Highlighter = m.Highlighter
+	body := ui.RenderBuffer(m.Buffer, m.Cursor.X, m.Cursor.Y, *m.Viewport, display)
+	if m.Diff != nil {
+		body = ui.RenderDiff(m.DiffTitle, m.Diff, m.Viewport.Width, m.Viewport.Height)
+	}
+	if m.UndoPanel {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, body, m.renderUndoPanel())
+	}
+	if m.Picker.open {
+		body = m.renderPicker()
+	}
+	if m.QuitDialog.open {
+		body = m.renderQuitDialog()
+	}
+	var tabs string
+	if len(m.Docs) > 1 {
+		tabs = m.renderTabBar() + "\n"
+	}
+	return tabs + ui.RenderStatusBar(m.File.FilePath, m.Buffer.IsDirty(), m.cursorColumn(), m.Cursor.Y, m.File.FormatInfo(), m.Width) + "\n" +
+		body + "\n" +
+		m.renderHelpBar() + "\n" +
+		m.renderMessageLine()
 }
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"editGo/data"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain keeps config, history and swap files out of the real home
+// directory, and autosaves only on focus loss so it never races a test.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "editgo-app")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", dir)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	os.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	configDir := filepath.Join(dir, "config", "editgo")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		panic(err)
+	}
+	config := []byte(`{"autosave": "focus"}`)
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), config, 0644); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestModel opens paths like the editor does at startup. Whatever is
+// still open when the test ends is discarded.
+func newTestModel(t *testing.T, paths ...string) *Model {
+	t.Helper()
+	m := NewModel(paths...)
+	t.Cleanup(func() {
+		for _, d := range m.Docs {
+			d.discard()
+		}
+	})
+	return &m
+}
+
+// send passes msgs to m in turn and returns the command from the last.
+func send(m *Model, msgs ...tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	for _, msg := range msgs {
+		var next tea.Model
+		next, cmd = m.Update(msg)
+		*m = next.(Model)
+	}
+	return cmd
+}
+
+// typeText sends s one key at a time.
+func typeText(m *Model, s string) tea.Cmd {
+	var cmd tea.Cmd
+	for _, r := range s {
+		cmd = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return cmd
+}
+
+// execute types line into the command line and runs it.
+func execute(m *Model, line string) tea.Cmd {
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlE})
+	typeText(m, line)
+	return send(m, tea.KeyMsg{Type: tea.KeyEnter})
+}
+
+// quits reports whether cmd ends the program. Commands still waiting,
+// like an autosave waiter, don't count.
+func quits(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	msgs := make(chan tea.Msg, 1)
+	go func() { msgs <- cmd() }()
+	select {
+	case msg := <-msgs:
+		switch msg := msg.(type) {
+		case tea.QuitMsg:
+			return true
+		case tea.BatchMsg:
+			return slices.ContainsFunc(msg, quits)
+		}
+	case <-time.After(50 * time.Millisecond):
+	}
+	return false
+}
+
+func TestModel_TypingEditsActiveBuffer(t *testing.T) {
+	m := newTestModel(t)
+	typeText(m, "hi")
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	typeText(m, "yo")
+	if got := m.Buffer.LineCount(); got != 2 {
+		t.Fatalf("expected 2 lines, got %d", got)
+	}
+	if got := string(m.Buffer.GetLine(1)); got != "yo" {
+		t.Errorf("expected second line %q, got %q", "yo", got)
+	}
+	if !m.Buffer.IsDirty() {
+		t.Errorf("expected the buffer dirty")
+	}
+}
+
+// swapFiles lists the swap files of unnamed buffers.
+func swapFiles(t *testing.T) []string {
+	t.Helper()
+	dir, err := data.StateDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "swap", "noname-*.swp"))
+	return paths
+}
+
+func TestModel_QuitDiscardsSwap(t *testing.T) {
+	m := newTestModel(t)
+	typeText(m, "draft")
+	lines, _ := m.Buffer.Snapshot(false)
+	if err := m.File.Swap.Write("", lines); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(swapFiles(t)); got != 1 {
+		t.Fatalf("expected 1 swap file, got %d", got)
+	}
+	if !quits(execute(m, "q!")) {
+		t.Fatalf("expected :q! to quit")
+	}
+	if got := swapFiles(t); len(got) != 0 {
+		t.Errorf("expected the swap removed, got %q", got)
+	}
+}
+
+func TestModel_PageByDisplayRowsWhenWrapped(t *testing.T) {
+	m := newTestModel(t)
+	send(m, tea.WindowSizeMsg{Width: 20, Height: 8}, tea.KeyMsg{Type: tea.KeyF4})
+	typeText(m, strings.Repeat("a", 300)) // one line, taller than the window
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlHome})
+
+	height := m.Viewport.Height
+	for page := 1; page <= 3; page++ {
+		send(m, tea.KeyMsg{Type: tea.KeyPgDown})
+		row, _ := m.wrap().Locate(m.Buffer.GetLine(0), m.Cursor.X)
+		if row != page*height {
+			t.Fatalf("page %d: expected the cursor on row %d, got %d", page, page*height, row)
+		}
+		if onScreen := row - m.Viewport.TopRow; onScreen < 0 || onScreen >= height {
+			t.Fatalf("page %d: cursor row %d off screen, top row %d", page, row, m.Viewport.TopRow)
+		}
+	}
+	send(m, tea.KeyMsg{Type: tea.KeyPgUp})
+	if row, _ := m.wrap().Locate(m.Buffer.GetLine(0), m.Cursor.X); row != 2*height {
+		t.Errorf("expected PgUp to go back a page to row %d, got %d", 2*height, row)
+	}
+}
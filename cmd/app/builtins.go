@@ -0,0 +1,454 @@
+package app
+
+import (
+	"editGo/config"
+	"editGo/data"
+	"editGo/ui"
+	"errors"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	commands.register(&command{
+		name: "w", aliases: []string{"write"}, usage: "[path]", maxArgs: 1,
+		help:     "save, or save as path (! overwrites); asks for a path if there is none",
+		complete: completeFile,
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			cmd, _, err := m.write(call)
+			return cmd, err
+		},
+	})
+	commands.register(&command{
+		name: "q", aliases: []string{"quit"},
+		help: "quit (! discards unsaved changes)",
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			if !call.bang {
+				if err := m.checkUnsaved(); err != nil {
+					return nil, err
+				}
+			}
+			return m.quit(), nil
+		},
+	})
+	commands.register(&command{
+		name: "wq", aliases: []string{"x"}, usage: "[path]", maxArgs: 1,
+		help:     "save and quit",
+		complete: completeFile,
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			_, saved, err := m.write(call)
+			if m.SaveAs.open {
+				// An unnamed buffer quits once the prompt has saved it.
+				m.SaveAs.after = func(m *Model) tea.Cmd {
+					cmd, err := m.quitAfterWrite(call.bang)
+					if err != nil {
+						m.StatusMessage = "Error: " + err.Error()
+					}
+					return cmd
+				}
+				return nil, nil
+			}
+			if !saved {
+				return nil, err
+			}
+			return m.quitAfterWrite(call.bang)
+		},
+	})
+	commands.register(&command{
+		name: "e", aliases: []string{"edit"}, usage: "[path]", maxArgs: 1,
+		help:     "open path in a buffer, or reload the file (! discards unsaved changes)",
+		complete: completeFile,
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			if len(call.args) == 1 {
+				return m.openDocument(expandHome(call.args[0])), nil
+			}
+			if m.File.FilePath == "" {
+				return nil, errors.New("no file name")
+			}
+			if m.Buffer.IsDirty() && !call.bang {
+				return nil, errors.New("unsaved changes (add ! to discard them)")
+			}
+			return m.reload(), nil
+		},
+	})
+	commands.register(&command{
+		name: "bn", aliases: []string{"bnext"},
+		help: "switch to the next buffer",
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			m.cycleBuffer(1)
+			return nil, nil
+		},
+	})
+	commands.register(&command{
+		name: "bp", aliases: []string{"bprevious", "bprev"},
+		help: "switch to the previous buffer",
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			m.cycleBuffer(-1)
+			return nil, nil
+		},
+	})
+	commands.register(&command{
+		name: "b", aliases: []string{"buffer"}, usage: "number|name", minArgs: 1, maxArgs: 1,
+		help:     "switch to a buffer by its number in the tab bar or its file name",
+		complete: completeBuffer,
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			i, err := m.bufferIndex(call.args[0])
+			if err != nil {
+				return nil, err
+			}
+			m.switchTo(i)
+			return nil, nil
+		},
+	})
+	commands.register(&command{
+		name: "bd", aliases: []string{"bdelete"}, usage: "[number|name]", maxArgs: 1,
+		help:     "close the buffer, or the one named (! discards unsaved changes)",
+		complete: completeBuffer,
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			i := m.activeIndex()
+			if len(call.args) == 1 {
+				var err error
+				if i, err = m.bufferIndex(call.args[0]); err != nil {
+					return nil, err
+				}
+			}
+			d := m.Docs[i]
+			if d.Buffer.IsDirty() && !call.bang {
+				return nil, fmt.Errorf("%s has unsaved changes (add ! to discard them)", displayName(d.File.FilePath))
+			}
+			active := m.Document
+			m.Document = d
+			cmd := m.closeDocument()
+			if active != d {
+				m.Document = active
+			}
+			return cmd, nil
+		},
+	})
+	commands.register(&command{
+		name: "ls", aliases: []string{"buffers"},
+		help: "list the open buffers",
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			m.StatusMessage = m.listBuffers()
+			return nil, nil
+		},
+	})
+	commands.register(&command{
+		name: "earlier", usage: "duration", minArgs: 1, maxArgs: 1,
+		help: "go back to the text as it was, e.g. 30s or 5m ago",
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			return nil, m.timeTravel(call.args[0], true)
+		},
+	})
+	commands.register(&command{
+		name: "later", usage: "duration", minArgs: 1, maxArgs: 1,
+		help: "go forward through undone changes by a duration, e.g. 30s or 5m",
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			return nil, m.timeTravel(call.args[0], false)
+		},
+	})
+	commands.register(&command{
+		name: "set", usage: "[option[=value]]...", maxArgs: -1,
+		help:     "change settings, or show them all",
+		complete: completeOption,
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			return nil, m.set(call.args)
+		},
+	})
+	commands.register(&command{
+		name: "theme", usage: "[name]", maxArgs: 1,
+		help: "switch colour theme, or show the current one",
+		complete: func(m *Model, prefix string) []string {
+			return completeWords(ui.ThemeNames(config.ThemeDir()), prefix)
+		},
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			if len(call.args) == 1 {
+				if err := setTheme(call.args[0]); err != nil {
+					return nil, err
+				}
+			}
+			m.StatusMessage = "Theme: " + ui.CurrentTheme()
+			return nil, nil
+		},
+	})
+	commands.register(&command{
+		name: "help", aliases: []string{"h"}, usage: "[command]", maxArgs: 1,
+		help: "list commands, or describe one",
+		complete: func(m *Model, prefix string) []string {
+			return completeWords(commands.names(), prefix)
+		},
+		run: func(m *Model, call commandCall) (tea.Cmd, error) {
+			if len(call.args) == 0 {
+				var names []string
+				for _, c := range commands.commands {
+					names = append(names, c.name)
+				}
+				m.StatusMessage = "Commands: " + strings.Join(names, " ") + " (:help <command> for more)"
+				return nil, nil
+			}
+			c, ok := commands.lookup(call.args[0])
+			if !ok {
+				return nil, fmt.Errorf("not a command: %s", call.args[0])
+			}
+			m.StatusMessage = ":" + strings.TrimSpace(c.name+" "+c.usage) + " - " + c.help
+			return nil, nil
+		},
+	})
+}
+
+func completeFile(m *Model, prefix string) []string {
+	return completePaths(prefix)
+}
+
+func completeBuffer(m *Model, prefix string) []string {
+	var names []string
+	for _, d := range m.Docs {
+		if d.File.FilePath != "" {
+			names = append(names, d.File.FilePath)
+		}
+	}
+	return completeWords(names, prefix)
+}
+
+// bufferIndex finds the buffer arg refers to: by its number in the tab
+// bar, its path, or failing that its base name.
+func (m Model) bufferIndex(arg string) (int, error) {
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(m.Docs) {
+			return 0, fmt.Errorf("no buffer %d", n)
+		}
+		return n - 1, nil
+	}
+	if i := m.findDocument(expandHome(arg)); i >= 0 {
+		return i, nil
+	}
+	for i, d := range m.Docs {
+		if d.File.FilePath != "" && filepath.Base(d.File.FilePath) == arg {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no buffer %s", arg)
+}
+
+// checkUnsaved fails naming the buffers with unsaved changes, if any.
+func (m Model) checkUnsaved() error {
+	if dirty := m.dirtyBuffers(); len(dirty) > 0 {
+		return fmt.Errorf("unsaved changes in %s (add ! to discard them)", strings.Join(dirty, ", "))
+	}
+	return nil
+}
+
+// write saves for :w and :wq, to the path given or else to the file's
+// own, and reports whether it did. With a bang it also overwrites files it
+// would otherwise refuse to.
+func (m *Model) write(call commandCall) (tea.Cmd, bool, error) {
+	path := m.File.FilePath
+	if len(call.args) == 1 {
+		path = expandHome(call.args[0])
+	}
+	switch {
+	case path == "":
+		m.SaveAs.start()
+		return nil, false, nil
+	case path != m.File.FilePath:
+		cmd, err := m.saveAs(path, call.bang)
+		return cmd, err == nil, err
+	}
+	if call.bang {
+		if err := m.File.AcceptExternal(); err != nil {
+			return nil, false, err
+		}
+	}
+	return nil, m.save(), nil
+}
+
+// quitAfterWrite finishes :wq once the buffer is saved. Other buffers with
+// unsaved changes stop it unless force is set.
+func (m *Model) quitAfterWrite(force bool) (tea.Cmd, error) {
+	if !force {
+		if err := m.checkUnsaved(); err != nil {
+			return nil, err
+		}
+	}
+	return m.quit(), nil
+}
+
+// option is a setting :set can show and change.
+type option struct {
+	name    string
+	aliases []string
+	// flag options are set by their name alone and cleared by "no" and
+	// their name, e.g. "wrap" and "nowrap".
+	flag   bool
+	values []string // offered by completion
+	get    func(m *Model) string
+	set    func(m *Model, value string) error
+}
+
+var options = []option{
+	{
+		name: "number", aliases: []string{"nu"}, flag: true,
+		values: []string{"off", "absolute", "relative", "hybrid"},
+		get:    func(m *Model) string { return m.Display.Gutter.Numbers.String() },
+		set: func(m *Model, value string) error {
+			if value == "on" {
+				value = "absolute"
+			}
+			numbers, err := ui.ParseLineNumbers(value)
+			if err == nil {
+				m.Display.Gutter.Numbers = numbers
+			}
+			return err
+		},
+	},
+	{
+		name: "wrap", flag: true,
+		get: func(m *Model) string { return onOff(m.Display.Wrap) },
+		set: func(m *Model, value string) error { return parseOnOff(value, &m.Display.Wrap) },
+	},
+	{
+		name: "wordwrap", flag: true,
+		get: func(m *Model) string { return onOff(m.Display.WordWrap) },
+		set: func(m *Model, value string) error { return parseOnOff(value, &m.Display.WordWrap) },
+	},
+	{
+		name: "tabwidth", aliases: []string{"ts"},
+		get: func(m *Model) string { return strconv.Itoa(m.Display.TabWidth) },
+		set: func(m *Model, value string) error { return parseCount(value, 1, &m.Display.TabWidth) },
+	},
+	{
+		name: "scrolloff", aliases: []string{"so"},
+		get: func(m *Model) string { return strconv.Itoa(m.Viewport.ScrollOff) },
+		set: func(m *Model, value string) error {
+			if err := parseCount(value, 0, &m.Config.ScrollOff); err != nil {
+				return err
+			}
+			for _, d := range m.Docs {
+				d.Viewport.ScrollOff = m.Config.ScrollOff
+			}
+			return nil
+		},
+	},
+	{
+		name: "sidescrolloff", aliases: []string{"siso"},
+		get: func(m *Model) string { return strconv.Itoa(m.Viewport.SideScrollOff) },
+		set: func(m *Model, value string) error {
+			if err := parseCount(value, 0, &m.Config.SideScrollOff); err != nil {
+				return err
+			}
+			for _, d := range m.Docs {
+				d.Viewport.SideScrollOff = m.Config.SideScrollOff
+			}
+			return nil
+		},
+	},
+	{
+		name: "fileformat", aliases: []string{"ff"},
+		values: []string{"lf", "crlf", "cr"},
+		get:    func(m *Model) string { return strings.ToLower(m.File.LineEnding.String()) },
+		set: func(m *Model, value string) error {
+			le, err := data.ParseLineEnding(value)
+			if err != nil {
+				return err
+			}
+			return m.setLineEnding(le)
+		},
+	},
+}
+
+func findOption(name string) (*option, bool) {
+	for i, opt := range options {
+		if opt.name == name || slices.Contains(opt.aliases, name) {
+			return &options[i], true
+		}
+	}
+	return nil, false
+}
+
+// set applies :set arguments, each "name", "noname" or "name=value", and
+// shows the resulting values; with none it shows every option.
+func (m *Model) set(args []string) error {
+	var shown []string
+	if len(args) == 0 {
+		for _, opt := range options {
+			shown = append(shown, opt.name+"="+opt.get(m))
+		}
+	}
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		opt, ok := findOption(name)
+		if !ok && !hasValue && strings.HasPrefix(name, "no") {
+			if opt, ok = findOption(name[2:]); ok && opt.flag {
+				value, hasValue = "off", true
+			} else {
+				ok = false
+			}
+		}
+		if !ok {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if !hasValue && opt.flag {
+			value, hasValue = "on", true
+		}
+		if hasValue {
+			if err := opt.set(m, value); err != nil {
+				return fmt.Errorf("%s: %w", opt.name, err)
+			}
+		}
+		shown = append(shown, opt.name+"="+opt.get(m))
+	}
+	m.StatusMessage = strings.Join(shown, " ")
+	return nil
+}
+
+// completeOption offers option names, "no" forms of flags, and the
+// values of an option whose "=" has been typed.
+func completeOption(m *Model, prefix string) []string {
+	if name, value, ok := strings.Cut(prefix, "="); ok {
+		opt, found := findOption(name)
+		if !found {
+			return nil
+		}
+		var matches []string
+		for _, v := range completeWords(opt.values, value) {
+			matches = append(matches, name+"="+v)
+		}
+		return matches
+	}
+	var words []string
+	for _, opt := range options {
+		if opt.flag {
+			words = append(words, opt.name, "no"+opt.name)
+		}
+		if !opt.flag || len(opt.values) > 0 {
+			words = append(words, opt.name+"=")
+		}
+	}
+	return completeWords(words, prefix)
+}
+
+func parseOnOff(value string, target *bool) error {
+	switch value {
+	case "on", "true", "yes":
+		*target = true
+	case "off", "false", "no":
+		*target = false
+	default:
+		return fmt.Errorf("expected on or off, got %q", value)
+	}
+	return nil
+}
+
+// parseCount sets target to value, a whole number no less than least.
+func parseCount(value string, least int, target *int) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < least {
+		return fmt.Errorf("expected a number of at least %d, got %q", least, value)
+	}
+	*target = n
+	return nil
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"editGo/data"
+	"editGo/ui"
+	"reflect"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		args   []string
+		status string
+		err    string
+	}{
+		{[]string{"wrap"}, "wrap=on", ""},
+		{[]string{"nowrap"}, "wrap=off", ""},
+		{[]string{"wrap=on", "wordwrap"}, "wrap=on wordwrap=on", ""},
+		{[]string{"wrap"}, "wrap=on", ""},
+		{[]string{"nu"}, "number=absolute", ""},
+		{[]string{"nonumber"}, "number=off", ""},
+		{[]string{"number=relative"}, "number=relative", ""},
+		{[]string{"ts=8"}, "tabwidth=8", ""},
+		{[]string{"tabwidth"}, "tabwidth=8", ""}, // no value shows it
+		{[]string{"so=2", "siso=7"}, "scrolloff=2 sidescrolloff=7", ""},
+		{[]string{"ff=crlf"}, "fileformat=crlf", ""},
+		{[]string{"ts=0"}, "", `tabwidth: expected a number of at least 1, got "0"`},
+		{[]string{"wrap=maybe"}, "", `wrap: expected on or off, got "maybe"`},
+		{[]string{"notabwidth"}, "", `unknown option "notabwidth"`}, // not a flag
+		{[]string{"bogus"}, "", `unknown option "bogus"`},
+	}
+	m := newTestModel(t)
+	for _, tt := range tests {
+		m.StatusMessage = ""
+		err := m.set(tt.args)
+		var msg string
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != tt.err || m.StatusMessage != tt.status {
+			t.Errorf("set %q: expected %q, error %q, got %q, error %q", tt.args, tt.status, tt.err, m.StatusMessage, msg)
+		}
+	}
+
+	if !m.Display.Wrap || !m.Display.WordWrap || m.Display.Gutter.Numbers != ui.NumbersRelative || m.Display.TabWidth != 8 {
+		t.Errorf("expected the settings applied, got %+v", m.Display)
+	}
+	if m.Viewport.ScrollOff != 2 || m.Viewport.SideScrollOff != 7 {
+		t.Errorf("expected the scroll-off applied, got %d, %d", m.Viewport.ScrollOff, m.Viewport.SideScrollOff)
+	}
+	if m.File.LineEnding != data.CRLF {
+		t.Errorf("expected CRLF, got %v", m.File.LineEnding)
+	}
+}
+
+func TestSet_ShowsEveryOption(t *testing.T) {
+	m := newTestModel(t)
+	if err := m.set(nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "number=off wrap=off wordwrap=off tabwidth=4 scrolloff=3 sidescrolloff=5 fileformat=lf"
+	if m.StatusMessage != want {
+		t.Errorf("expected %q, got %q", want, m.StatusMessage)
+	}
+}
+
+func TestCompleteOption(t *testing.T) {
+	m := newTestModel(t)
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"w", []string{"wordwrap", "wrap"}},
+		{"nu", []string{"number", "number="}},
+		{"no", []string{"nonumber", "nowordwrap", "nowrap"}},
+		{"tab", []string{"tabwidth="}}, // not a flag
+		{"nota", nil},                  // only flags have a "no" form
+		{"wrap=", nil},                 // no values to offer
+		{"number=", []string{"number=absolute", "number=hybrid", "number=off", "number=relative"}},
+		{"nu=r", []string{"nu=relative"}}, // completes under the name typed
+		{"ff=cr", []string{"ff=cr", "ff=crlf"}},
+		{"bogus=", nil},
+	}
+	for _, tt := range tests {
+		if got := completeOption(m, tt.prefix); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completeOption(%q): expected %q, got %q", tt.prefix, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+)
+
+// maxHistory is how many command lines are remembered.
+const maxHistory = 100
+
+// commandLine is the ":" input opened with Ctrl+E.
+type commandLine struct {
+	open    bool
+	input   lineInput
+	history []string
+	browse  int      // entry of history shown; len(history) for the new line
+	draft   string   // the new line, kept while browsing history
+	matches []string // completions left to choose from, shown as a hint
+}
+
+func (c *commandLine) start() {
+	c.open = true
+	c.input.set("")
+	c.browse = len(c.history)
+	c.matches = nil
+}
+
+// remember adds line to the history, unless it repeats the last entry.
+func (c *commandLine) remember(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(c.history); n == 0 || c.history[n-1] != line {
+		c.history = append(c.history, line)
+	}
+	if len(c.history) > maxHistory {
+		c.history = c.history[len(c.history)-maxHistory:]
+	}
+}
+
+// browseHistory steps through earlier lines (step -1) or back towards the
+// one being typed (step 1).
+func (c *commandLine) browseHistory(step int) {
+	if c.browse == len(c.history) {
+		c.draft = c.input.String()
+	}
+	c.browse = min(max(c.browse+step, 0), len(c.history))
+	if c.browse == len(c.history) {
+		c.input.set(c.draft)
+	} else {
+		c.input.set(c.history[c.browse])
+	}
+}
+
+// updateCommandLine handles keys while the command line is open.
+func (m Model) updateCommandLine(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := &m.Command
+	switch {
+	case msg.Type == tea.KeyEsc, msg.Type == tea.KeyCtrlC:
+		c.open = false
+		return m, nil
+	case msg.Type == tea.KeyEnter:
+		line := c.input.String()
+		c.open = false
+		c.remember(line)
+		return m, m.runCommand(line)
+	case msg.Type == tea.KeyUp:
+		c.browseHistory(-1)
+	case msg.Type == tea.KeyDown:
+		c.browseHistory(1)
+	case msg.Type == tea.KeyTab:
+		m.completeCommand()
+		return m, nil
+	case msg.Type == tea.KeyBackspace && len(c.input.text) == 0:
+		c.open = false
+	default:
+		c.input.edit(msg)
+	}
+	c.matches = nil
+	return m, nil
+}
+
+// completeCommand completes the end of the command line: the command
+// name, or else its last argument, as far as the candidates agree.
+func (m *Model) completeCommand() {
+	c := &m.Command
+	line := c.input.String()
+	lead := len(line) - len(strings.TrimLeft(line, " :"))
+	nameEnd := lead + len(commandName(line[lead:]))
+
+	var head string
+	var matches []string
+	if nameEnd == len(line) {
+		head, matches = line[:lead], completeWords(commands.names(), line[lead:])
+	} else {
+		cmd, ok := commands.lookup(line[lead:nameEnd])
+		if !ok || cmd.complete == nil {
+			return
+		}
+		argsStart := nameEnd
+		if strings.HasPrefix(line[nameEnd:], "!") {
+			argsStart++
+		}
+		if argsStart == len(line) {
+			return // nothing typed after the name yet, not even a space
+		}
+		args, last, err := splitArgs(line[argsStart:])
+		if err != nil {
+			return
+		}
+		last += argsStart
+		prefix := ""
+		if last < len(line) {
+			prefix = args[len(args)-1]
+		}
+		head, matches = line[:last], cmd.complete(m, prefix)
+	}
+	if len(matches) == 0 {
+		return
+	}
+
+	completed := escapeArg(commonPrefix(matches))
+	if len(matches) == 1 && !isDirPath(completed) && !strings.HasSuffix(completed, "=") {
+		completed += " "
+	}
+	c.input.set(head + completed)
+	c.matches = nil
+	if len(matches) > 1 {
+		c.matches = matches
+	}
+}
+
+// commandHint is shown in place of the help bar while the command line
+// is open: the completions to choose from, or help for the command typed.
+func (m Model) commandHint() string {
+	if len(m.Command.matches) > 0 {
+		return completionHint(m.Command.matches)
+	}
+	if call, err := parseCall(m.Command.input.String()); err == nil {
+		if c, ok := commands.lookup(call.name); ok {
+			return " :" + strings.TrimSpace(c.name+" "+c.usage) + " - " + c.help
+		}
+	}
+	return " Tab Complete | ↑/↓ History | Enter Run | Esc Cancel"
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCommandLine_Remember(t *testing.T) {
+	var c commandLine
+	for _, line := range []string{"w", "", "  ", "w", "set wrap", "w"} {
+		c.remember(line)
+	}
+	if want := []string{"w", "set wrap", "w"}; !reflect.DeepEqual(c.history, want) {
+		t.Errorf("expected %q, got %q", want, c.history)
+	}
+
+	for i := range maxHistory + 10 {
+		c.remember(fmt.Sprint("e ", i))
+	}
+	if len(c.history) != maxHistory || c.history[0] != "e 10" {
+		t.Errorf("expected the last %d lines kept, got %d from %q", maxHistory, len(c.history), c.history[0])
+	}
+}
+
+func TestCommandLine_BrowseHistory(t *testing.T) {
+	c := commandLine{history: []string{"a", "b", "c"}}
+	c.start()
+	c.input.set("dr")
+	steps := []struct {
+		step int
+		want string
+	}{
+		{-1, "c"},
+		{-1, "b"},
+		{-1, "a"},
+		{-1, "a"}, // stays on the oldest
+		{1, "b"},
+		{1, "c"},
+		{1, "dr"}, // back to the line being typed
+		{1, "dr"},
+	}
+	for i, s := range steps {
+		c.browseHistory(s.step)
+		if got := c.input.String(); got != s.want {
+			t.Fatalf("step %d (%+d): expected %q, got %q", i, s.step, s.want, got)
+		}
+	}
+}
+
+func TestCommandLine_RecallsRunLines(t *testing.T) {
+	m := newTestModel(t)
+	execute(m, "set wrap")
+	execute(m, "set nowrap")
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlE}, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyUp})
+	if got := m.Command.input.String(); got != "set wrap" {
+		t.Errorf("expected Up twice to recall %q, got %q", "set wrap", got)
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"my file.txt", "notes.md", "nothing.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newTestModel(t)
+	tests := []struct {
+		line    string
+		want    string
+		matches []string
+	}{
+		{"wr", "write ", nil},
+		{":la", ":later ", nil},
+		{"set", "set ", nil},
+		{"bp", "bp", []string{"bp", "bprev", "bprevious"}},
+		{"zz", "zz", nil},
+		{"set tabw", "set tabwidth=", nil},
+		{"set nu", "set number", []string{"number", "number="}},
+		{"set nonu", "set nonumber ", nil},
+		{"set ff=c", "set ff=cr", []string{"ff=cr", "ff=crlf"}},
+		{"set wrap ff=l", "set wrap ff=lf ", nil},
+		{"set ", "set ", nil}, // everything matches, nothing in common
+		{"set", "set ", nil},
+		{"q", "q", []string{"q", "quit"}},
+		{"q ", "q ", nil}, // :q takes no arguments to complete
+		{"set \"nu", "set \"nu", nil},
+		{"e " + dir + "/my", "e " + dir + `/my\ file.txt `, nil},
+		{"e! " + dir + "/no", "e! " + dir + "/not", []string{dir + "/notes.md", dir + "/nothing.md"}},
+	}
+	for _, tt := range tests {
+		m.Command.input.set(tt.line)
+		m.completeCommand()
+		if got := m.Command.input.String(); got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.line, tt.want, got)
+		}
+		if tt.matches != nil && !reflect.DeepEqual(m.Command.matches, tt.matches) {
+			t.Errorf("%q: expected matches %q, got %q", tt.line, tt.matches, m.Command.matches)
+		}
+	}
+}
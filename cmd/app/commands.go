@@ -0,0 +1,191 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// command is something the command line runs, typed as
+// ":name[!] args...". The "!" usually forces it past a safety check.
+type command struct {
+	name    string
+	aliases []string
+	usage   string // the arguments, for help
+	help    string
+	minArgs int
+	maxArgs int // -1 for any number
+	// complete, when set, lists candidates for an argument typed so far
+	// as prefix.
+	complete func(m *Model, prefix string) []string
+	run      func(m *Model, call commandCall) (tea.Cmd, error)
+}
+
+// commandCall is a parsed command line.
+type commandCall struct {
+	name string
+	bang bool
+	args []string
+}
+
+// registry finds commands by name or alias.
+type registry struct {
+	commands []*command
+	byName   map[string]*command
+}
+
+func newRegistry() *registry {
+	return &registry{byName: map[string]*command{}}
+}
+
+// register adds c. Two commands claiming one name is a bug, so it panics.
+func (r *registry) register(c *command) {
+	for _, name := range append([]string{c.name}, c.aliases...) {
+		if _, taken := r.byName[name]; taken {
+			panic("command registered twice: " + name)
+		}
+		r.byName[name] = c
+	}
+	r.commands = append(r.commands, c)
+}
+
+func (r *registry) lookup(name string) (*command, bool) {
+	c, ok := r.byName[name]
+	return c, ok
+}
+
+// names lists every name and alias, sorted.
+func (r *registry) names() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commands holds the built-in commands, registered in builtins.go.
+var commands = newRegistry()
+
+// runCommand parses and runs line, reporting any error in the status
+// line.
+func (m *Model) runCommand(line string) tea.Cmd {
+	call, err := parseCall(line)
+	if err != nil || call.name == "" {
+		if err != nil {
+			m.StatusMessage = "Error: " + err.Error()
+		}
+		return nil
+	}
+	c, ok := commands.lookup(call.name)
+	if !ok {
+		m.StatusMessage = "Error: not a command: " + call.name
+		return nil
+	}
+	if len(call.args) < c.minArgs || (c.maxArgs >= 0 && len(call.args) > c.maxArgs) {
+		m.StatusMessage = "Error: usage: :" + strings.TrimSpace(c.name+" "+c.usage)
+		return nil
+	}
+	cmd, err := c.run(m, call)
+	if err != nil {
+		m.StatusMessage = "Error: " + err.Error()
+	}
+	return cmd
+}
+
+// parseCall splits a command line into the command name, a "!" after it
+// and the arguments.
+func parseCall(line string) (commandCall, error) {
+	line = strings.TrimLeft(line, " :")
+	name := commandName(line)
+	call := commandCall{name: name}
+	rest := line[len(name):]
+	if strings.HasPrefix(rest, "!") {
+		call.bang = true
+		rest = rest[1:]
+	}
+	if rest != "" && !unicode.IsSpace(rune(rest[0])) {
+		return call, fmt.Errorf("not a command: %s", strings.Fields(line)[0])
+	}
+	args, _, err := splitArgs(rest)
+	call.args = args
+	return call, err
+}
+
+// commandName returns the letters line starts with.
+func commandName(line string) string {
+	end := strings.IndexFunc(line, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < 0 {
+		return line
+	}
+	return line[:end]
+}
+
+var errUnterminatedQuote = errors.New("unterminated quote")
+
+// splitArgs splits s at spaces, except inside quotes or after a
+// backslash. A backslash only escapes a space, quote or backslash, so
+// Windows paths can be typed as they are. last is the byte offset where
+// the final argument starts, or len(s) if s ends between arguments.
+func splitArgs(s string) (args []string, last int, err error) {
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	last = len(s)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		next, nextSize := utf8.DecodeRuneInString(s[i+size:])
+		if !inArg && !unicode.IsSpace(r) {
+			inArg, last = true, i
+		}
+		switch {
+		case r == '\\' && quote != '\'' && strings.ContainsRune(" \t\"'\\", next) && nextSize > 0:
+			arg.WriteRune(next)
+			size += nextSize
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg, last = false, len(s)
+			}
+		default:
+			arg.WriteRune(r)
+		}
+		i += size
+	}
+	if quote != 0 {
+		return args, last, errUnterminatedQuote
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, last, nil
+}
+
+// escapeArg quotes the characters splitArgs would otherwise split or
+// drop. A backslash is only doubled where splitArgs would take it as an
+// escape, so Windows paths stay as they are.
+func escapeArg(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		escapes := r == '\\' && i+1 < len(runes) && strings.ContainsRune(" \t\"'\\", runes[i+1])
+		if escapes || strings.ContainsRune(" \t\"'", r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		in   string
+		args []string
+		last int
+		err  error
+	}{
+		{"", nil, 0, nil},
+		{"  ", nil, 2, nil},
+		{"a b", []string{"a", "b"}, 2, nil},
+		{" a  b ", []string{"a", "b"}, 6, nil}, // ends between arguments
+		{`"my file.txt" x`, []string{"my file.txt", "x"}, 14, nil},
+		{`'it''s'`, []string{"its"}, 0, nil},
+		{`"say 'hi'"`, []string{"say 'hi'"}, 0, nil},
+		{`my\ file.txt`, []string{"my file.txt"}, 0, nil},
+		{`a\\b`, []string{`a\b`}, 0, nil},
+		{`\"quoted\"`, []string{`"quoted"`}, 0, nil},
+		{`C:\Users\me`, []string{`C:\Users\me`}, 0, nil}, // Windows paths as typed
+		{`'a\ b'`, []string{`a\ b`}, 0, nil},             // no escapes in single quotes
+		{`trailing\`, []string{`trailing\`}, 0, nil},
+		{"x 日本", []string{"x", "日本"}, 2, nil},
+		{`x "open`, []string{"x"}, 2, errUnterminatedQuote},
+	}
+	for _, tt := range tests {
+		args, last, err := splitArgs(tt.in)
+		if !reflect.DeepEqual(args, tt.args) || last != tt.last || err != tt.err {
+			t.Errorf("splitArgs(%q): expected %q, %d, %v, got %q, %d, %v",
+				tt.in, tt.args, tt.last, tt.err, args, last, err)
+		}
+	}
+}
+
+func TestParseCall(t *testing.T) {
+	tests := []struct {
+		line string
+		want commandCall
+		err  string
+	}{
+		{"w", commandCall{name: "w"}, ""},
+		{":w", commandCall{name: "w"}, ""},
+		{" : wq! out.txt", commandCall{name: "wq", bang: true, args: []string{"out.txt"}}, ""},
+		{"q!", commandCall{name: "q", bang: true}, ""},
+		{`e "a b.txt"`, commandCall{name: "e", args: []string{"a b.txt"}}, ""},
+		{"set nu wrap", commandCall{name: "set", args: []string{"nu", "wrap"}}, ""},
+		{"", commandCall{}, ""},
+		{"w2", commandCall{name: "w"}, "not a command: w2"},
+		{"q!!", commandCall{name: "q", bang: true}, "not a command: q!!"},
+		{"123", commandCall{}, "not a command: 123"},
+		{`e "open`, commandCall{name: "e"}, errUnterminatedQuote.Error()},
+	}
+	for _, tt := range tests {
+		call, err := parseCall(tt.line)
+		var msg string
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != tt.err {
+			t.Errorf("parseCall(%q): expected error %q, got %q", tt.line, tt.err, msg)
+		}
+		if call.name != tt.want.name || call.bang != tt.want.bang || (tt.err == "" && !reflect.DeepEqual(call.args, tt.want.args)) {
+			t.Errorf("parseCall(%q): expected %+v, got %+v", tt.line, tt.want, call)
+		}
+	}
+}
+
+func TestEscapeArg(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain.txt", "plain.txt"},
+		{"my file.txt", `my\ file.txt`},
+		{"tab\there", "tab\\\there"},
+		{`say "hi"`, `say\ \"hi\"`},
+		{"it's", `it\'s`},
+		{`C:\Users\me`, `C:\Users\me`},
+		{`a\ b`, `a\\\ b`},
+		{`ends\\`, `ends\\\`},
+	}
+	for _, tt := range tests {
+		got := escapeArg(tt.in)
+		if got != tt.want {
+			t.Errorf("escapeArg(%q): expected %q, got %q", tt.in, tt.want, got)
+		}
+		// whatever escapeArg produces, splitArgs must read back as one argument
+		if args, _, err := splitArgs(got); err != nil || len(args) != 1 || args[0] != tt.in {
+			t.Errorf("splitArgs(escapeArg(%q)) = %q, %v", tt.in, args, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// expandHome replaces a leading "~" with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// completePaths lists the files and directories that start with prefix,
+// directories with a trailing separator. Hidden entries are left out
+// unless prefix names one.
+func completePaths(prefix string) []string {
+	dir, base := filepath.Split(prefix)
+	read := expandHome(dir)
+	if read == "" {
+		read = "."
+	}
+	entries, err := os.ReadDir(read)
+	if err != nil {
+		return nil
+	}
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		matches = append(matches, dir+name)
+	}
+	return matches
+}
+
+// completeWords lists the words that start with prefix, sorted.
+func completeWords(words []string, prefix string) []string {
+	var matches []string
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			matches = append(matches, word)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// commonPrefix returns the longest prefix shared by all of words.
+func commonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+// isDirPath reports whether path, as completed, names a directory.
+func isDirPath(path string) bool {
+	return strings.HasSuffix(path, string(filepath.Separator))
+}
+
+// completionHint lists matches by their last element, for showing in
+// place of the help bar.
+func completionHint(matches []string) string {
+	shown := make([]string, len(matches))
+	for i, match := range matches {
+		shown[i] = filepath.Base(match)
+		if isDirPath(match) {
+			shown[i] += string(filepath.Separator)
+		}
+	}
+	return " " + strings.Join(shown, "  ")
+}
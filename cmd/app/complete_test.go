@@ -0,0 +1,23 @@
+package app
+
+import "testing"
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"write"}, "write"},
+		{[]string{"bp", "bprev", "bprevious"}, "bp"},
+		{[]string{"notes.md", "nothing.md"}, "not"},
+		{[]string{"abc", "xyz"}, ""},
+		{[]string{"日本語", "日本"}, "日本"},
+		{[]string{"日本", "日曜"}, "日"}, // never splits a character
+	}
+	for _, tt := range tests {
+		if got := commonPrefix(tt.words); got != tt.want {
+			t.Errorf("commonPrefix(%q): expected %q, got %q", tt.words, tt.want, got)
+		}
+	}
+}
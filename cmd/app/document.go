@@ -0,0 +1,237 @@
+package app
+
+import (
+	"editGo/data"
+	"editGo/editor"
+	"editGo/syntax"
+	"editGo/ui"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Document is one open buffer with everything that belongs to it. The
+// Model embeds the active one, so m.Buffer is always the buffer on screen.
+type Document struct {
+	Buffer      *editor.TextBuffer
+	Cursor      *editor.CursorPointer
+	File        *data.FileManager
+	UndoStack   *editor.UndoManager
+	AutoSaver   *data.AutoSave
+	Viewport    *ui.Viewport
+	Highlighter *syntax.Highlighter
+
+	Recovery       *data.Recovery    // leftover swap file awaiting a decision
+	ExternalChange bool              // file changed on disk, awaiting a decision
+	DiskDeleted    bool              // the change is that the file was deleted
+	Diff           []editor.DiffLine // shown instead of the buffer when set
+	DiffTitle      string
+}
+
+// newDocument sets up file for editing, with the cursor at the top and
+// its own autosave, and looks for a swap file to recover it from.
+func (m *Model) newDocument(file *data.FileManager) *Document {
+	file.KeepBackup = m.Config.KeepBackup
+	viewport := ui.NewViewport(m.Width, m.Height-m.chromeLines())
+	viewport.ScrollOff = m.Config.ScrollOff
+	viewport.SideScrollOff = m.Config.SideScrollOff
+	d := &Document{
+		Buffer:      file.Buffer,
+		Cursor:      editor.NewCursor(0, 0),
+		File:        file,
+		UndoStack:   file.History,
+		Viewport:    viewport,
+		Highlighter: syntax.NewHighlighter(syntax.ForFile(file.FilePath)),
+	}
+	if err := d.checkRecovery(); err != nil {
+		m.StatusMessage = "Error: " + err.Error()
+	}
+	d.startAutoSave(m.Config.Autosave)
+	return d
+}
+
+// startAutoSave starts a new autosave for the file with the policy spec,
+// or the default policy if spec is invalid.
+func (d *Document) startAutoSave(spec string) {
+	d.AutoSaver = data.NewAutoSave(d.File, 5*time.Second)
+	if policy, err := data.ParsePolicy(spec); err == nil {
+		d.AutoSaver.Policy = policy
+	}
+	d.AutoSaver.Start()
+}
+
+// discard stops autosave and drops the swap file, for when the buffer is
+// closed without saving.
+func (d *Document) discard() {
+	d.AutoSaver.Stop()
+	d.File.Swap.Remove()
+}
+
+// autoSaveMsg is the outcome of one autosave of doc by saver.
+type autoSaveMsg struct {
+	doc    *Document
+	saver  *data.AutoSave
+	result data.SaveResult
+}
+
+// waitForAutoSave delivers the next autosave outcome of d as a message.
+func waitForAutoSave(d *Document) tea.Cmd {
+	saver := d.AutoSaver
+	return func() tea.Msg {
+		result, ok := <-saver.Results
+		if !ok {
+			return nil
+		}
+		return autoSaveMsg{doc: d, saver: saver, result: result}
+	}
+}
+
+// isScratch reports whether the active buffer is the empty unnamed one
+// the editor starts with, which opening a file replaces.
+func (m Model) isScratch() bool {
+	return m.File.FilePath == "" && !m.Buffer.IsDirty() &&
+		m.Buffer.LineCount() == 1 && len(m.Buffer.GetLine(0)) == 0
+}
+
+func (m Model) activeIndex() int {
+	return max(slices.Index(m.Docs, m.Document), 0)
+}
+
+// findDocument returns the index of the buffer editing path, or -1.
+func (m Model) findDocument(path string) int {
+	abs, err := filepath.Abs(path)
+	if path == "" || err != nil {
+		return -1
+	}
+	for i, d := range m.Docs {
+		if d.File.FilePath == "" {
+			continue
+		}
+		if other, err := filepath.Abs(d.File.FilePath); err == nil && other == abs {
+			return i
+		}
+	}
+	return -1
+}
+
+// switchTo makes buffer i the active one.
+func (m *Model) switchTo(i int) {
+	if i < 0 || i >= len(m.Docs) {
+		return
+	}
+	m.UndoStack.Boundary()
+	m.Document = m.Docs[i]
+	m.UndoPanel = false
+	m.StatusMessage = fmt.Sprintf("Buffer %d: %s", i+1, displayName(m.File.FilePath))
+}
+
+// cycleBuffer switches step buffers along, wrapping around.
+func (m *Model) cycleBuffer(step int) {
+	n := len(m.Docs)
+	m.switchTo(((m.activeIndex()+step)%n + n) % n)
+}
+
+// openDocument shows path: its buffer if it is already open, or else a new
+// one after the active buffer, which replaces the active one if that is
+// still the empty scratch buffer.
+func (m *Model) openDocument(path string) tea.Cmd {
+	if i := m.findDocument(path); i >= 0 {
+		m.switchTo(i)
+		return nil
+	}
+	file, status := openFile(path)
+	if status == "" {
+		status = "Opened " + path
+	}
+	m.StatusMessage = status
+	d := m.newDocument(file)
+	i := m.activeIndex()
+	if m.isScratch() {
+		m.discard()
+		m.Docs = slices.Clone(m.Docs)
+		m.Docs[i] = d
+	} else {
+		m.Docs = slices.Insert(slices.Clone(m.Docs), i+1, d)
+	}
+	m.Document = d
+	m.UndoPanel = false
+	return waitForAutoSave(d)
+}
+
+// reload reads the active buffer's file again, dropping any unsaved
+// changes.
+func (m *Model) reload() tea.Cmd {
+	file, status := openFile(m.File.FilePath)
+	if status == "" {
+		status = "Reloaded " + m.File.FilePath
+	}
+	m.StatusMessage = status
+	i := m.activeIndex()
+	m.discard()
+	d := m.newDocument(file)
+	m.Docs = slices.Clone(m.Docs)
+	m.Docs[i] = d
+	m.Document = d
+	return waitForAutoSave(d)
+}
+
+// closeDocument closes the active buffer, dropping any unsaved changes.
+// Closing the last buffer leaves an empty one.
+func (m *Model) closeDocument() tea.Cmd {
+	i := m.activeIndex()
+	name := displayName(m.File.FilePath)
+	m.discard()
+	m.Docs = slices.Delete(slices.Clone(m.Docs), i, i+1)
+	var cmd tea.Cmd
+	if len(m.Docs) == 0 {
+		file, _ := data.NewEmptyFile("")
+		d := m.newDocument(file)
+		m.Docs = []*Document{d}
+		cmd = waitForAutoSave(d)
+	}
+	m.Document = m.Docs[min(i, len(m.Docs)-1)]
+	m.UndoPanel = false
+	m.StatusMessage = "Closed " + name
+	return cmd
+}
+
+// requestClose closes the active buffer, first asking about unsaved
+// changes if it has any.
+func (m *Model) requestClose() tea.Cmd {
+	if !m.Buffer.IsDirty() {
+		return m.closeDocument()
+	}
+	m.QuitDialog = quitDialog{open: true, closing: true}
+	return nil
+}
+
+// listBuffers describes every buffer, the active one in brackets.
+func (m Model) listBuffers() string {
+	items := make([]string, len(m.Docs))
+	for i, d := range m.Docs {
+		item := fmt.Sprintf("%d:%s", i+1, displayName(d.File.FilePath))
+		if d.Buffer.IsDirty() {
+			item += " ✱"
+		}
+		if d == m.Document {
+			item = "[" + item + "]"
+		}
+		items[i] = item
+	}
+	return strings.Join(items, "  ")
+}
+
+func (m Model) renderTabBar() string {
+	tabs := make([]ui.Tab, len(m.Docs))
+	for i, d := range m.Docs {
+		name := "[No Name]"
+		if d.File.FilePath != "" {
+			name = filepath.Base(d.File.FilePath)
+		}
+		tabs[i] = ui.Tab{Name: name, Dirty: d.Buffer.IsDirty()}
+	}
+	return ui.RenderTabBar(tabs, m.activeIndex(), m.Width)
+}
@@ -0,0 +1,262 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles creates each of names in a new directory holding its own
+// name, and returns their paths.
+func writeFiles(t *testing.T, names ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[i], []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+// docPaths lists the file of every buffer, in tab order.
+func docPaths(m *Model) []string {
+	paths := make([]string, len(m.Docs))
+	for i, d := range m.Docs {
+		paths[i] = d.File.FilePath
+	}
+	return paths
+}
+
+func TestOpenDocument_ReplacesScratch(t *testing.T) {
+	paths := writeFiles(t, "a.txt", "b.txt")
+	m := newTestModel(t)
+	scratch := m.Document
+
+	execute(m, "e "+paths[0])
+	if got := docPaths(m); len(got) != 1 || got[0] != paths[0] {
+		t.Fatalf("expected the empty buffer replaced by %s, got %q", paths[0], got)
+	}
+	if m.Document == scratch || string(m.Buffer.GetLine(0)) != "a.txt" {
+		t.Errorf("expected a new buffer holding the file, got %q", m.Buffer.GetLine(0))
+	}
+
+	execute(m, "e "+paths[1])
+	if got := docPaths(m); strings.Join(got, "|") != strings.Join(paths, "|") {
+		t.Errorf("expected a second buffer after the first, got %q", got)
+	}
+
+	execute(m, "e "+paths[0])
+	if len(m.Docs) != 2 || m.File.FilePath != paths[0] {
+		t.Errorf("expected reopening to switch to the open buffer, got %q active %s", docPaths(m), m.File.FilePath)
+	}
+}
+
+func TestOpenDocument_KeepsEditedScratch(t *testing.T) {
+	paths := writeFiles(t, "a.txt")
+	m := newTestModel(t)
+	typeText(m, "keep me")
+
+	execute(m, "e "+paths[0])
+	if got := docPaths(m); len(got) != 2 || got[0] != "" || got[1] != paths[0] {
+		t.Fatalf("expected the edited buffer kept before %s, got %q", paths[0], got)
+	}
+	if got := string(m.Docs[0].Buffer.GetLine(0)); got != "keep me" {
+		t.Errorf("expected the edit kept, got %q", got)
+	}
+}
+
+func TestOpenDocument_InsertsAfterActive(t *testing.T) {
+	paths := writeFiles(t, "a.txt", "b.txt", "c.txt")
+	m := newTestModel(t, paths[0], paths[1])
+	execute(m, "e "+paths[2])
+	want := []string{paths[0], paths[2], paths[1]}
+	if got := docPaths(m); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if m.chromeLines() != 4 {
+		t.Errorf("expected room for the tab bar, got %d chrome lines", m.chromeLines())
+	}
+}
+
+func TestSwitchBuffers(t *testing.T) {
+	paths := writeFiles(t, "a.txt", "b.txt", "c.txt")
+	m := newTestModel(t, paths...)
+	steps := []struct {
+		key  tea.Msg
+		line string
+		want int
+	}{
+		{tea.KeyMsg{Type: tea.KeyCtrlPgDown}, "", 1},
+		{tea.KeyMsg{Type: tea.KeyCtrlPgDown}, "", 2},
+		{tea.KeyMsg{Type: tea.KeyCtrlPgDown}, "", 0}, // wraps around
+		{tea.KeyMsg{Type: tea.KeyCtrlPgUp}, "", 2},
+		{nil, "bn", 0},
+		{nil, "bp", 2},
+		{nil, "b 2", 1},
+		{nil, "b c.txt", 2},
+		{nil, "b " + paths[0], 0},
+	}
+	for _, s := range steps {
+		if s.key != nil {
+			send(m, s.key)
+		} else {
+			execute(m, s.line)
+		}
+		if got := m.activeIndex(); got != s.want {
+			t.Errorf("%v%s: expected buffer %d, got %d", s.key, s.line, s.want, got)
+		}
+	}
+	execute(m, "b 4")
+	if m.activeIndex() != 0 || !strings.Contains(m.StatusMessage, "no buffer 4") {
+		t.Errorf("expected an error for a missing buffer, got %q", m.StatusMessage)
+	}
+}
+
+func TestCloseDocument_Last(t *testing.T) {
+	paths := writeFiles(t, "a.txt")
+	m := newTestModel(t, paths[0])
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlW})
+	if len(m.Docs) != 1 || m.File.FilePath != "" || !m.isScratch() {
+		t.Errorf("expected an empty buffer left, got %q", docPaths(m))
+	}
+	if m.Document != m.Docs[0] {
+		t.Errorf("expected the new buffer active")
+	}
+}
+
+func TestCloseDocument_ActivatesNeighbour(t *testing.T) {
+	paths := writeFiles(t, "a.txt", "b.txt", "c.txt")
+	m := newTestModel(t, paths...)
+	execute(m, "b 2")
+	execute(m, "bd")
+	if m.File.FilePath != paths[2] {
+		t.Errorf("expected the next buffer active, got %s", m.File.FilePath)
+	}
+	execute(m, "bd")
+	if m.File.FilePath != paths[0] {
+		t.Errorf("expected the previous buffer active after closing the last tab, got %s", m.File.FilePath)
+	}
+}
+
+func TestCloseDocument_Inactive(t *testing.T) {
+	paths := writeFiles(t, "a.txt", "b.txt", "c.txt")
+	m := newTestModel(t, paths...)
+	execute(m, "b 3")
+	typeText(m, "x")
+	execute(m, "b 1")
+	active := m.Document
+
+	execute(m, "bd 2")
+	want := []string{paths[0], paths[2]}
+	if got := docPaths(m); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if m.Document != active {
+		t.Errorf("expected the active buffer to stay active, got %s", m.File.FilePath)
+	}
+
+	execute(m, "bd 2")
+	if len(m.Docs) != 2 || !strings.Contains(m.StatusMessage, "unsaved changes") {
+		t.Errorf("expected a dirty buffer kept, got %q with %q", docPaths(m), m.StatusMessage)
+	}
+	execute(m, "bd! 2")
+	if got := docPaths(m); len(got) != 1 || m.Document != active {
+		t.Errorf("expected only the active buffer left, got %q", got)
+	}
+}
+
+func TestReload(t *testing.T) {
+	paths := writeFiles(t, "a.txt", "b.txt")
+	m := newTestModel(t, paths...)
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlPgDown})
+	typeText(m, "x")
+	if err := os.WriteFile(paths[1], []byte("on disk\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	execute(m, "e")
+	if string(m.Buffer.GetLine(0)) != "xb.txt" || !strings.Contains(m.StatusMessage, "unsaved changes") {
+		t.Errorf("expected :e to keep unsaved changes, got %q with %q", m.Buffer.GetLine(0), m.StatusMessage)
+	}
+
+	execute(m, "e!")
+	if got := string(m.Buffer.GetLine(0)); got != "on disk" || m.Buffer.IsDirty() {
+		t.Errorf("expected :e! to read the file again, got %q dirty=%v", got, m.Buffer.IsDirty())
+	}
+	if m.activeIndex() != 1 || len(m.Docs) != 2 || m.ExternalChange {
+		t.Errorf("expected the buffer replaced in place, got %q active %d", docPaths(m), m.activeIndex())
+	}
+}
+
+func TestSaveAndQuit_ResumesAfterSaveAs(t *testing.T) {
+	paths := writeFiles(t, "a.txt", "b.txt")
+	unnamed := filepath.Join(filepath.Dir(paths[0]), "new.txt")
+	m := newTestModel(t, paths[0], "", paths[1])
+	for range m.Docs {
+		typeText(m, "x")
+		send(m, tea.KeyMsg{Type: tea.KeyCtrlPgDown})
+	}
+
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlQ})
+	if quits(send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})) {
+		t.Fatalf("expected the unnamed buffer to be asked a path before quitting")
+	}
+	if !m.SaveAs.open || m.activeIndex() != 1 {
+		t.Fatalf("expected Save As for the unnamed buffer, got open=%v active %d", m.SaveAs.open, m.activeIndex())
+	}
+	if got := readFile(t, paths[0]); got != "xa.txt\n" {
+		t.Errorf("expected the buffer before it saved, got %q", got)
+	}
+
+	typeText(m, unnamed)
+	if !quits(send(m, tea.KeyMsg{Type: tea.KeyEnter})) {
+		t.Fatalf("expected the save-all to go on and quit")
+	}
+	for path, want := range map[string]string{paths[1]: "xb.txt\n", unnamed: "x\n"} {
+		if got := readFile(t, path); got != want {
+			t.Errorf("%s: expected %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestBlur_AutosavesEveryBuffer(t *testing.T) {
+	paths := writeFiles(t, "a.txt", "b.txt")
+	m := newTestModel(t, paths...)
+	for range m.Docs {
+		typeText(m, "x")
+		send(m, tea.KeyMsg{Type: tea.KeyCtrlPgDown})
+	}
+
+	send(m, tea.BlurMsg{})
+	for i, d := range m.Docs {
+		select {
+		case result := <-d.AutoSaver.Results:
+			send(m, autoSaveMsg{doc: d, saver: d.AutoSaver, result: result})
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected buffer %d saved when the terminal lost focus", i)
+		}
+	}
+	for i, want := range []string{"xa.txt\n", "xb.txt\n"} {
+		if got := readFile(t, paths[i]); got != want || m.Docs[i].Buffer.IsDirty() {
+			t.Errorf("%s: expected %q saved, got %q dirty=%v", paths[i], want, got, m.Docs[i].Buffer.IsDirty())
+		}
+	}
+}
+
+func TestRenderTabBar_ShownForSeveralBuffers(t *testing.T) {
+	paths := writeFiles(t, "a.txt", "b.txt")
+	m := newTestModel(t, paths[0])
+	if view := m.View(); strings.Contains(view, " 1 a.txt ") || m.chromeLines() != 3 {
+		t.Errorf("expected no tab bar for one buffer, got %q", view)
+	}
+	execute(m, "e "+paths[1])
+	if view := m.View(); !strings.Contains(view, "1 a.txt") || !strings.Contains(view, "2 b.txt") {
+		t.Errorf("expected both buffers in the tab bar, got %q", view)
+	}
+}
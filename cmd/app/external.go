@@ -0,0 +1,118 @@
+package app
+
+import (
+	"editGo/data"
+	"editGo/editor"
+	"errors"
+	tea "github.com/charmbracelet/bubbletea"
+	"time"
+)
+
+// diskCheckInterval is how often the open file is polled for changes
+// made by other programs.
+const diskCheckInterval = 2 * time.Second
+
+type diskCheckMsg struct{}
+
+func checkDiskLater() tea.Cmd {
+	return tea.Tick(diskCheckInterval, func(time.Time) tea.Msg { return diskCheckMsg{} })
+}
+
+// checkDisk raises the reload prompt for every buffer whose file changed
+// on disk. A background buffer shows it once it is switched to.
+func (m *Model) checkDisk() {
+	for _, d := range m.Docs {
+		if d.ExternalChange || d.Recovery != nil {
+			continue
+		}
+		changed, err := d.File.CheckExternal()
+		if err != nil {
+			m.StatusMessage = "Error: " + err.Error()
+			continue
+		}
+		d.ExternalChange = changed
+		d.DiskDeleted = changed && d.File.Deleted()
+		if changed && d != m.Document {
+			m.StatusMessage = d.File.FilePath + " changed on disk"
+		}
+	}
+}
+
+// saveError reports a failed save of d, turning a refused overwrite into
+// the reload prompt, shown once d is the active buffer.
+func (m *Model) saveError(d *Document, err error) {
+	if errors.Is(err, data.ErrExternallyModified) {
+		d.ExternalChange = true
+		d.DiskDeleted = d.File.Deleted()
+		return
+	}
+	m.StatusMessage = "Error: " + err.Error()
+}
+
+// updateExternal handles the reload/keep/diff prompt.
+func (m Model) updateExternal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.DiskDeleted {
+		return m.updateDeleted(msg)
+	}
+	switch msg.String() {
+	case "r":
+		if err := m.File.Reload(); err != nil {
+			m.StatusMessage = "Error: " + err.Error()
+		} else {
+			m.StatusMessage = "Reloaded " + m.File.FilePath
+		}
+		m.Highlighter.Reset()
+		m.Cursor.Clamp(m.Buffer)
+		m.closeExternal()
+	case "k":
+		if err := m.File.AcceptExternal(); err != nil {
+			m.StatusMessage = "Error: " + err.Error()
+		} else {
+			m.StatusMessage = "Keeping your version; it will overwrite the file on save"
+		}
+		m.closeExternal()
+	case "d":
+		if m.Diff != nil {
+			m.Diff = nil
+			break
+		}
+		disk, err := m.File.ReadDisk()
+		if err != nil {
+			disk = [][]rune{}
+		}
+		m.Diff = editor.DiffLines(disk, m.Buffer.Lines())
+		m.DiffTitle = "Changes (- on disk, + yours)"
+	}
+	return m, nil
+}
+
+// updateDeleted handles the keep/close prompt for a file that was
+// deleted on disk, where there is nothing to reload or diff against.
+func (m Model) updateDeleted(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "k":
+		if err := m.File.AcceptExternal(); err != nil {
+			m.StatusMessage = "Error: " + err.Error()
+		} else {
+			m.StatusMessage = "Keeping your version; saving will create the file again"
+		}
+		m.closeExternal()
+	case "c":
+		m.closeExternal()
+		return m, m.closeDocument()
+	}
+	return m, nil
+}
+
+func (m *Model) closeExternal() {
+	m.ExternalChange = false
+	m.DiskDeleted = false
+	m.Diff = nil
+}
+
+func (m Model) externalPrompt() string {
+	if m.DiskDeleted {
+		return m.File.FilePath + " was deleted on disk: [k]eep mine  [c]lose"
+	}
+	return m.File.FilePath + " changed on disk: [r]eload  [k]eep mine  [d]iff"
+}
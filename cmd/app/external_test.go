@@ -0,0 +1,84 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckDisk_FlagsEveryBuffer(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte("before\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newTestModel(t, first, second)
+	if err := os.WriteFile(second, []byte("changed elsewhere\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	send(m, diskCheckMsg{})
+	if m.Docs[0].ExternalChange {
+		t.Errorf("expected the unchanged active buffer left alone")
+	}
+	if !m.Docs[1].ExternalChange {
+		t.Fatalf("expected the background buffer flagged")
+	}
+	if !strings.Contains(m.StatusMessage, second) {
+		t.Errorf("expected the change reported, got %q", m.StatusMessage)
+	}
+
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlPgDown})
+	if !strings.Contains(m.renderMessageLine(), "changed on disk") {
+		t.Errorf("expected the reload prompt once switched to, got %q", m.renderMessageLine())
+	}
+	typeText(m, "r")
+	if m.ExternalChange || string(m.Buffer.GetLine(0)) != "changed elsewhere" {
+		t.Errorf("expected r to reload, got %q", m.Buffer.GetLine(0))
+	}
+}
+
+func TestCheckDisk_DeletedFile(t *testing.T) {
+	for _, key := range []string{"k", "c"} {
+		t.Run(key, func(t *testing.T) {
+			m, path := dirtyFile(t)
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			send(m, diskCheckMsg{})
+			if !m.ExternalChange || !strings.Contains(m.renderMessageLine(), "deleted on disk") {
+				t.Fatalf("expected the deletion prompt, got %q", m.renderMessageLine())
+			}
+			typeText(m, "r") // nothing to reload
+			if !m.ExternalChange {
+				t.Fatalf("expected r to leave the prompt open")
+			}
+
+			typeText(m, key)
+			if m.ExternalChange {
+				t.Fatalf("expected %s to close the prompt", key)
+			}
+			if key == "c" {
+				if len(m.Docs) != 1 || m.File.FilePath != "" {
+					t.Errorf("expected the buffer closed, got %q", docPaths(m))
+				}
+				return
+			}
+			if m.File.FilePath != path || !m.Buffer.IsDirty() {
+				t.Errorf("expected the dirty buffer kept, got %s dirty=%v", m.File.FilePath, m.Buffer.IsDirty())
+			}
+			send(m, diskCheckMsg{})
+			if m.ExternalChange {
+				t.Errorf("expected the prompt not to come back once answered")
+			}
+			send(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+			if got := readFile(t, path); got != "new saved\n" {
+				t.Errorf("expected saving to create the file again, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"slices"
+)
+
+// lineInput is a one-line text field, for the command line and prompts.
+type lineInput struct {
+	text []rune
+	pos  int // cursor, in runes
+}
+
+func (in lineInput) String() string {
+	return string(in.text)
+}
+
+func (in *lineInput) set(s string) {
+	in.text = []rune(s)
+	in.pos = len(in.text)
+}
+
+// edit applies an editing key to the field, reporting whether msg was one.
+func (in *lineInput) edit(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		runes := msg.Runes
+		if msg.Type == tea.KeySpace {
+			runes = []rune{' '}
+		}
+		in.text = slices.Insert(slices.Clone(in.text), in.pos, runes...)
+		in.pos += len(runes)
+	case tea.KeyBackspace:
+		if in.pos > 0 {
+			in.text = slices.Delete(slices.Clone(in.text), in.pos-1, in.pos)
+			in.pos--
+		}
+	case tea.KeyDelete:
+		if in.pos < len(in.text) {
+			in.text = slices.Delete(slices.Clone(in.text), in.pos, in.pos+1)
+		}
+	case tea.KeyLeft:
+		in.pos = max(in.pos-1, 0)
+	case tea.KeyRight:
+		in.pos = min(in.pos+1, len(in.text))
+	case tea.KeyHome, tea.KeyCtrlA:
+		in.pos = 0
+	case tea.KeyEnd, tea.KeyCtrlE:
+		in.pos = len(in.text)
+	case tea.KeyCtrlU: // delete to the start, as in a shell
+		in.text = slices.Clone(in.text[in.pos:])
+		in.pos = 0
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"editGo/ui"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// filePicker is the Ctrl+O overlay for choosing a file to open.
+type filePicker struct {
+	open     bool
+	dir      string // absolute
+	entries  []ui.FileEntry
+	filter   lineInput
+	selected int
+}
+
+// readDir shows the contents of dir, directories first, with the filter
+// cleared.
+func (p *filePicker) readDir(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	entries := make([]ui.FileEntry, 0, len(list))
+	for _, entry := range list {
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(dir, entry.Name())); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		entries = append(entries, ui.FileEntry{Name: entry.Name(), Dir: isDir})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Dir && !entries[j].Dir })
+
+	p.dir, p.entries = dir, entries
+	p.filter.set("")
+	p.selected = 0
+	return nil
+}
+
+// visible lists the entries matching the filter, ignoring case. Hidden
+// files only show once the filter starts with a dot, and ".." only while
+// there is no filter.
+func (p filePicker) visible() []ui.FileEntry {
+	filter := strings.ToLower(p.filter.String())
+	var shown []ui.FileEntry
+	if filter == "" && filepath.Dir(p.dir) != p.dir {
+		shown = append(shown, ui.FileEntry{Name: "..", Dir: true})
+	}
+	for _, entry := range p.entries {
+		if strings.HasPrefix(entry.Name, ".") && !strings.HasPrefix(filter, ".") {
+			continue
+		}
+		if strings.Contains(strings.ToLower(entry.Name), filter) {
+			shown = append(shown, entry)
+		}
+	}
+	return shown
+}
+
+// openPicker shows the directory of the current file, or the working
+// directory for an unnamed buffer.
+func (m *Model) openPicker() {
+	dir := "."
+	if m.File.FilePath != "" {
+		dir = filepath.Dir(m.File.FilePath)
+	}
+	m.Picker = filePicker{}
+	if err := m.Picker.readDir(dir); err != nil {
+		m.StatusMessage = "Error: " + err.Error()
+		return
+	}
+	m.Picker.open = true
+}
+
+// updatePicker handles keys while the file picker is open.
+func (m Model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.Picker
+	shown := p.visible()
+	switch {
+	case msg.Type == tea.KeyEsc, msg.Type == tea.KeyCtrlC, msg.Type == tea.KeyCtrlO:
+		p.open = false
+	case msg.Type == tea.KeyUp:
+		p.selected = max(p.selected-1, 0)
+	case msg.Type == tea.KeyDown:
+		p.selected = min(p.selected+1, max(len(shown)-1, 0))
+	case msg.Type == tea.KeyBackspace && len(p.filter.text) == 0:
+		m.pickDir(filepath.Dir(p.dir))
+	case msg.Type == tea.KeyEnter:
+		if p.selected >= len(shown) {
+			break
+		}
+		entry := shown[p.selected]
+		path := filepath.Join(p.dir, entry.Name)
+		if entry.Dir {
+			m.pickDir(path)
+			break
+		}
+		return m, m.pickFile(path)
+	default:
+		if p.filter.edit(msg) {
+			p.selected = 0
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) pickDir(dir string) {
+	if err := m.Picker.readDir(dir); err != nil {
+		m.StatusMessage = "Error: " + err.Error()
+	}
+}
+
+// pickFile opens path in a buffer. Files under the working directory are
+// named relative to it.
+func (m *Model) pickFile(path string) tea.Cmd {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	m.Picker.open = false
+	return m.openDocument(path)
+}
+
+func (m Model) renderPicker() string {
+	p := m.Picker
+	return ui.RenderFilePicker(ui.FilePicker{
+		Dir:       p.dir,
+		Filter:    p.filter.text,
+		FilterPos: p.filter.pos,
+		Entries:   p.visible(),
+		Selected:  p.selected,
+	}, m.Width, m.Viewport.Height)
+}
+
+func pickerHint() string {
+	return " ↑/↓ Select | Enter Open | Backspace Up | Type to Filter | Esc Cancel"
+}
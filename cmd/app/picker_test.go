@@ -0,0 +1,153 @@
+package app
+
+import (
+	"editGo/ui"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// pickerDir makes a directory with a few files, a hidden one, a
+// subdirectory and a link to it.
+func pickerDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"b.txt", "a.go", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "sub"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// entryNames lists entries by name, with a slash after directories.
+func entryNames(entries []ui.FileEntry) []string {
+	var names []string
+	for _, e := range entries {
+		name := e.Name
+		if e.Dir {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestFilePicker_ReadDir(t *testing.T) {
+	dir := pickerDir(t)
+	p := filePicker{selected: 3}
+	p.filter.set("old")
+	if err := p.readDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"link/", "sub/", ".hidden", "a.go", "b.txt"} // directories first
+	if got := entryNames(p.entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if p.dir != dir || p.filter.String() != "" || p.selected != 0 {
+		t.Errorf("expected %s with the filter and selection reset, got %s %q %d", dir, p.dir, p.filter.String(), p.selected)
+	}
+
+	if err := p.readDir(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+	if p.dir != dir || len(p.entries) != 5 {
+		t.Errorf("expected a failed read to leave the listing alone, got %s %q", p.dir, entryNames(p.entries))
+	}
+}
+
+func TestFilePicker_Visible(t *testing.T) {
+	dir := pickerDir(t)
+	var p filePicker
+	if err := p.readDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"../", "link/", "sub/", "a.go", "b.txt"}},
+		{"B", []string{"sub/", "b.txt"}}, // ignoring case
+		{"b.t", []string{"b.txt"}},
+		{".", []string{".hidden", "a.go", "b.txt"}}, // a dot shows hidden files
+		{".h", []string{".hidden"}},
+		{"zz", nil},
+	}
+	for _, tt := range tests {
+		p.filter.set(tt.filter)
+		if got := entryNames(p.visible()); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filter %q: expected %q, got %q", tt.filter, tt.want, got)
+		}
+	}
+
+	root := filePicker{dir: string(filepath.Separator)}
+	if got := entryNames(root.visible()); len(got) != 0 {
+		t.Errorf("expected no .. at the root, got %q", got)
+	}
+}
+
+func TestFilePicker_OpensChosenFile(t *testing.T) {
+	dir := pickerDir(t)
+	m := newTestModel(t, filepath.Join(dir, "a.go"))
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlO})
+	if !m.Picker.open || m.Picker.dir != dir {
+		t.Fatalf("expected the picker open on %s, got %v %s", dir, m.Picker.open, m.Picker.dir)
+	}
+
+	typeText(m, "b.t")
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.Picker.open {
+		t.Errorf("expected the picker closed")
+	}
+	if len(m.Docs) != 2 || m.File.FilePath != filepath.Join(dir, "b.txt") {
+		t.Errorf("expected b.txt opened in a second buffer, got %d buffers, active %s", len(m.Docs), m.File.FilePath)
+	}
+	if got := string(m.Buffer.GetLine(0)); got != "b.txt" {
+		t.Errorf("expected the file read, got %q", got)
+	}
+}
+
+func TestFilePicker_Navigates(t *testing.T) {
+	dir := pickerDir(t)
+	m := newTestModel(t, filepath.Join(dir, "a.go"))
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlO})
+
+	send(m, tea.KeyMsg{Type: tea.KeyUp})
+	if m.Picker.selected != 0 {
+		t.Errorf("expected the selection to stop at the top, got %d", m.Picker.selected)
+	}
+	for range 10 {
+		send(m, tea.KeyMsg{Type: tea.KeyDown})
+	}
+	if m.Picker.selected != 4 {
+		t.Errorf("expected the selection to stop at the last entry, got %d", m.Picker.selected)
+	}
+
+	typeText(m, "su")
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.Picker.dir != filepath.Join(dir, "sub") || m.Picker.filter.String() != "" {
+		t.Fatalf("expected to enter sub with the filter cleared, got %s %q", m.Picker.dir, m.Picker.filter.String())
+	}
+	send(m, tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.Picker.dir != dir {
+		t.Errorf("expected Backspace to go back up to %s, got %s", dir, m.Picker.dir)
+	}
+
+	send(m, tea.KeyMsg{Type: tea.KeyEnter}) // ".." is selected
+	if m.Picker.dir != filepath.Dir(dir) {
+		t.Errorf("expected .. to go up to %s, got %s", filepath.Dir(dir), m.Picker.dir)
+	}
+
+	send(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.Picker.open || len(m.Docs) != 1 {
+		t.Errorf("expected Esc to close the picker without opening anything")
+	}
+}
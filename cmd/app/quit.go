@@ -0,0 +1,142 @@
+package app
+
+import (
+	"editGo/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Buttons of the quit dialog, in order.
+const (
+	quitSave = iota
+	quitDiscard
+	quitCancel
+)
+
+var quitButtons = []string{"Save", "Discard", "Cancel"}
+
+// quitDialog asks what to do with unsaved changes before quitting, or
+// before closing the active buffer.
+type quitDialog struct {
+	open     bool
+	closing  bool
+	selected int
+}
+
+// requestQuit quits, first asking about unsaved changes if there are any.
+func (m *Model) requestQuit() tea.Cmd {
+	if len(m.dirtyBuffers()) == 0 {
+		return m.quit()
+	}
+	m.QuitDialog = quitDialog{open: true}
+	return nil
+}
+
+// dirtyBuffers names the buffers with unsaved changes.
+func (m Model) dirtyBuffers() []string {
+	var names []string
+	for _, d := range m.Docs {
+		if d.Buffer.IsDirty() {
+			names = append(names, displayName(d.File.FilePath))
+		}
+	}
+	return names
+}
+
+func displayName(path string) string {
+	if path == "" {
+		return "[No Name]"
+	}
+	return path
+}
+
+// updateQuitDialog handles keys while the quit dialog is open.
+func (m Model) updateQuitDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := &m.QuitDialog
+	switch {
+	case msg.Type == tea.KeyLeft, msg.Type == tea.KeyShiftTab:
+		d.selected = (d.selected + len(quitButtons) - 1) % len(quitButtons)
+	case msg.Type == tea.KeyRight, msg.Type == tea.KeyTab:
+		d.selected = (d.selected + 1) % len(quitButtons)
+	case msg.Type == tea.KeyEnter:
+		return m.chooseQuit(d.selected)
+	case msg.String() == "s":
+		return m.chooseQuit(quitSave)
+	case msg.String() == "d":
+		return m.chooseQuit(quitDiscard)
+	case msg.String() == "c", msg.Type == tea.KeyEsc:
+		return m.chooseQuit(quitCancel)
+	}
+	return m, nil
+}
+
+func (m Model) chooseQuit(choice int) (tea.Model, tea.Cmd) {
+	m.QuitDialog.open = false
+	switch {
+	case choice == quitSave && m.QuitDialog.closing:
+		return m, m.saveAndClose()
+	case choice == quitSave:
+		return m, m.saveAndQuit()
+	case choice == quitDiscard && m.QuitDialog.closing:
+		return m, m.closeDocument()
+	case choice == quitDiscard:
+		return m, m.quit()
+	}
+	return m, nil
+}
+
+// saveAndQuit saves every buffer with changes, showing each in turn, and
+// quits. An unnamed buffer is asked a path first, and the rest waits for
+// that; a failed save cancels it.
+func (m *Model) saveAndQuit() tea.Cmd {
+	for _, d := range m.Docs {
+		if !d.Buffer.IsDirty() {
+			continue
+		}
+		m.Document = d
+		if !m.saveOrAsk((*Model).saveAndQuit) {
+			return nil
+		}
+	}
+	return m.quit()
+}
+
+// saveAndClose saves the active buffer and closes it, the same way.
+func (m *Model) saveAndClose() tea.Cmd {
+	if m.Buffer.IsDirty() && !m.saveOrAsk((*Model).saveAndClose) {
+		return nil
+	}
+	return m.closeDocument()
+}
+
+// saveOrAsk saves the active buffer and reports whether it did. An unnamed
+// one opens the Save As prompt instead, which runs then once it saves.
+func (m *Model) saveOrAsk(then func(m *Model) tea.Cmd) bool {
+	if m.File.FilePath == "" {
+		m.SaveAs.start()
+		m.SaveAs.after = then
+		return false
+	}
+	return m.save()
+}
+
+func (m Model) renderQuitDialog() string {
+	dirty := m.dirtyBuffers()
+	lines := []string{"Save changes before quitting?", ""}
+	if m.QuitDialog.closing {
+		dirty = []string{displayName(m.File.FilePath)}
+		lines[0] = "Save changes before closing?"
+	}
+	for _, name := range dirty {
+		lines = append(lines, "  • "+name)
+	}
+	return ui.RenderDialog(ui.Dialog{
+		Title:    "Unsaved changes",
+		Lines:    lines,
+		Buttons:  quitButtons,
+		Selected: m.QuitDialog.selected,
+	}, m.Width, m.Viewport.Height)
+}
+
+func quitHint() string {
+	return " s Save | d Discard | c/Esc Cancel | ←/→ Choose | Enter Confirm"
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// dirtyFile opens a new model on a file holding "saved" and edits it.
+func dirtyFile(t *testing.T) (*Model, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "edit.txt")
+	if err := os.WriteFile(path, []byte("saved\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t, path)
+	typeText(m, "new ")
+	return m, path
+}
+
+func TestQuit_CleanQuitsAtOnce(t *testing.T) {
+	m := newTestModel(t)
+	if !quits(send(m, tea.KeyMsg{Type: tea.KeyCtrlQ})) {
+		t.Errorf("expected Ctrl+Q to quit with nothing unsaved")
+	}
+	if m.QuitDialog.open {
+		t.Errorf("expected no dialog")
+	}
+}
+
+func TestQuit_DialogChoices(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []tea.KeyMsg
+		quits bool
+		saved bool
+	}{
+		{"s saves", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("s")}}, true, true},
+		{"d discards", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("d")}}, true, false},
+		{"c cancels", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("c")}}, false, false},
+		{"Esc cancels", []tea.KeyMsg{{Type: tea.KeyEsc}}, false, false},
+		{"Enter picks Save", []tea.KeyMsg{{Type: tea.KeyEnter}}, true, true},
+		{"Right then Enter discards", []tea.KeyMsg{{Type: tea.KeyRight}, {Type: tea.KeyEnter}}, true, false},
+		{"Left wraps to Cancel", []tea.KeyMsg{{Type: tea.KeyLeft}, {Type: tea.KeyEnter}}, false, false},
+		{"Tab twice picks Cancel", []tea.KeyMsg{{Type: tea.KeyTab}, {Type: tea.KeyTab}, {Type: tea.KeyEnter}}, false, false},
+		{"other keys are ignored", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("x")}, {Type: tea.KeyRunes, Runes: []rune("d")}}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, path := dirtyFile(t)
+			if quits(send(m, tea.KeyMsg{Type: tea.KeyCtrlQ})) || !m.QuitDialog.open {
+				t.Fatalf("expected Ctrl+Q to ask about the unsaved changes")
+			}
+			var cmd tea.Cmd
+			for _, key := range tt.keys {
+				cmd = send(m, key)
+			}
+			if got := quits(cmd); got != tt.quits {
+				t.Errorf("expected quit %v, got %v", tt.quits, got)
+			}
+			if m.QuitDialog.open {
+				t.Errorf("expected the dialog closed")
+			}
+			if saved := strings.HasPrefix(readFile(t, path), "new saved"); saved != tt.saved {
+				t.Errorf("expected saved %v, file holds %q", tt.saved, readFile(t, path))
+			}
+			if !tt.quits && string(m.Buffer.GetLine(0)) != "new saved" {
+				t.Errorf("expected the edit kept, got %q", m.Buffer.GetLine(0))
+			}
+		})
+	}
+}
+
+func TestQuit_DialogListsDirtyBuffers(t *testing.T) {
+	m, path := dirtyFile(t)
+	execute(m, "e "+filepath.Join(filepath.Dir(path), "other.txt"))
+	typeText(m, "x")
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlQ})
+	dialog := m.renderQuitDialog()
+	for _, name := range []string{path, "other.txt"} {
+		if !strings.Contains(dialog, name) {
+			t.Errorf("expected %s listed in %q", name, dialog)
+		}
+	}
+}
+
+func TestQuit_CloseDialog(t *testing.T) {
+	m, path := dirtyFile(t)
+	other := filepath.Join(filepath.Dir(path), "other.txt")
+	execute(m, "e "+other)
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlPgUp})
+	if m.File.FilePath != path {
+		t.Fatalf("expected %s active, got %s", path, m.File.FilePath)
+	}
+
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlW})
+	if !m.QuitDialog.open || !m.QuitDialog.closing {
+		t.Fatalf("expected Ctrl+W to ask about the unsaved changes")
+	}
+	send(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if len(m.Docs) != 2 {
+		t.Errorf("expected cancel to keep the buffer, got %d buffers", len(m.Docs))
+	}
+
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlW})
+	if quits(send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})) {
+		t.Fatalf("expected closing a buffer not to quit")
+	}
+	if len(m.Docs) != 1 || m.File.FilePath != other {
+		t.Errorf("expected only %s left, got %d buffers, active %s", other, len(m.Docs), m.File.FilePath)
+	}
+	if got := readFile(t, path); !strings.HasPrefix(got, "new saved") {
+		t.Errorf("expected the buffer saved before closing, got %q", got)
+	}
+}
+
+func TestQuit_CloseDialogDiscards(t *testing.T) {
+	m, path := dirtyFile(t)
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlW})
+	send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if len(m.Docs) != 1 || m.File.FilePath != "" {
+		t.Errorf("expected an empty buffer left, got %d buffers, active %q", len(m.Docs), m.File.FilePath)
+	}
+	if got := readFile(t, path); got != "saved\n" {
+		t.Errorf("expected the file left alone, got %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"editGo/data"
+	"editGo/editor"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"slices"
+)
+
+// checkRecovery looks for a swap file left by a crashed session for the
+// file being opened. One that matches the file, because an autosave got
+// there before the crash, has nothing to recover and is dropped.
+func (d *Document) checkRecovery() error {
+	rec, err := data.FindSwap(d.File.FilePath)
+	if err != nil {
+		return fmt.Errorf("reading swap file: %w", err)
+	}
+	if rec != nil && d.File.FilePath != "" && slices.EqualFunc(rec.Lines, d.Buffer.Lines(), slices.Equal) {
+		return rec.Discard()
+	}
+	d.Recovery = rec
+	return nil
+}
+
+// updateRecovery handles the recover/diff/discard prompt.
+func (m Model) updateRecovery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "r":
+		m.UndoStack.Push(m.Buffer) // undo brings back the file as on disk
+		m.Buffer.SetLines(m.Recovery.Lines)
+		m.Highlighter.Reset()
+		m.Cursor.Clamp(m.Buffer)
+		m.finishRecovery("Recovered unsaved changes")
+	case "d":
+		if m.Diff == nil {
+			m.Diff = editor.DiffLines(m.Buffer.Lines(), m.Recovery.Lines)
+			m.DiffTitle = "Changes (- on disk, + swap file)"
+		} else {
+			m.Diff = nil
+		}
+	case "x":
+		m.finishRecovery("Discarded swap file")
+	}
+	return m, nil
+}
+
+func (m *Model) finishRecovery(status string) {
+	if err := m.Recovery.Discard(); err != nil {
+		status = "Error: " + err.Error()
+	}
+	m.StatusMessage = status
+	m.Recovery = nil
+	m.Diff = nil
+}
+
+func (m Model) recoveryPrompt() string {
+	return "Unsaved changes from " + m.Recovery.Time.Format("Jan 2 15:04") +
+		" found: [r]ecover  [d]iff  [x] discard"
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"strings"
+)
+
+// saveAsPrompt asks where to save an unnamed buffer.
+type saveAsPrompt struct {
+	open      bool
+	input     lineInput
+	matches   []string // completions left to choose from, shown as a hint
+	overwrite string   // an existing file waiting for the overwrite to be confirmed
+	err       string   // why the last Enter didn't save, shown as a hint
+	// after runs once the buffer is saved, for things that wait on it,
+	// like quitting
+	after func(m *Model) tea.Cmd
+}
+
+func (p *saveAsPrompt) start() {
+	*p = saveAsPrompt{open: true}
+}
+
+// updateSaveAs handles keys while the Save As prompt is open.
+func (m Model) updateSaveAs(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.SaveAs
+	if p.overwrite != "" {
+		switch msg.String() {
+		case "y":
+			return m.finishSaveAs(p.overwrite)
+		case "n", "esc":
+			p.overwrite = ""
+		}
+		return m, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		p.open = false
+	case tea.KeyTab:
+		matches := completePaths(p.input.String())
+		p.matches, p.err = nil, ""
+		if len(matches) > 0 {
+			p.input.set(commonPrefix(matches))
+		}
+		if len(matches) > 1 {
+			p.matches = matches
+		}
+		return m, nil
+	case tea.KeyEnter:
+		path := expandHome(strings.TrimSpace(p.input.String()))
+		if path == "" {
+			break
+		}
+		info, err := os.Stat(path)
+		switch {
+		case err == nil && info.IsDir():
+			// Keep the typed path so a file name can be added to it.
+			p.matches, p.err = nil, path+" is a directory"
+			return m, nil
+		case err == nil:
+			p.overwrite = path
+		default:
+			return m.finishSaveAs(path)
+		}
+	default:
+		p.input.edit(msg)
+	}
+	p.matches, p.err = nil, ""
+	return m, nil
+}
+
+func (m Model) finishSaveAs(path string) (tea.Model, tea.Cmd) {
+	after := m.SaveAs.after
+	m.SaveAs = saveAsPrompt{}
+	cmd, err := m.saveAs(path, true)
+	if err != nil {
+		m.StatusMessage = "Error: " + err.Error()
+		return m, nil
+	}
+	if after != nil {
+		return m, tea.Batch(cmd, after(&m))
+	}
+	return m, cmd
+}
+
+func (m Model) overwritePrompt() string {
+	return m.SaveAs.overwrite + " exists: overwrite it? [y]es  [n]o"
+}
+
+func (m Model) saveAsHint() string {
+	if m.SaveAs.err != "" {
+		return " Error: " + m.SaveAs.err + " | Esc Cancel"
+	}
+	if len(m.SaveAs.matches) > 0 {
+		return completionHint(m.SaveAs.matches)
+	}
+	return " Tab Complete | Enter Save | Esc Cancel"
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readFile returns the content of path, failing the test if it can't.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestSaveAs_SavesToTypedPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.txt")
+	m := newTestModel(t)
+	typeText(m, "hello")
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	if !m.SaveAs.open {
+		t.Fatalf("expected Ctrl+S on an unnamed buffer to ask for a path")
+	}
+	typeText(m, path)
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.SaveAs.open {
+		t.Errorf("expected the prompt closed after saving")
+	}
+	if m.File.FilePath != path || m.Buffer.IsDirty() {
+		t.Errorf("expected a clean buffer named %s, got %q dirty=%v", path, m.File.FilePath, m.Buffer.IsDirty())
+	}
+	if got := readFile(t, path); !strings.HasPrefix(got, "hello") {
+		t.Errorf("expected the buffer written, got %q", got)
+	}
+}
+
+func TestSaveAs_ConfirmsOverwrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "taken.txt")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t)
+	typeText(m, "new")
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	typeText(m, path)
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.SaveAs.overwrite != path {
+		t.Fatalf("expected to be asked about overwriting %s, got %q", path, m.SaveAs.overwrite)
+	}
+
+	typeText(m, "n")
+	if !m.SaveAs.open || m.SaveAs.overwrite != "" || m.SaveAs.input.String() != path {
+		t.Errorf("expected n to go back to the prompt with the path kept, got %+v", m.SaveAs)
+	}
+	if got := readFile(t, path); got != "old\n" {
+		t.Errorf("expected the file left alone, got %q", got)
+	}
+
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	typeText(m, "y")
+	if m.SaveAs.open || m.File.FilePath != path {
+		t.Errorf("expected y to save to %s, got %q", path, m.File.FilePath)
+	}
+	if got := readFile(t, path); !strings.HasPrefix(got, "new") {
+		t.Errorf("expected the file overwritten, got %q", got)
+	}
+}
+
+func TestSaveAs_DirectoryKeepsPrompt(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestModel(t)
+	typeText(m, "text")
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlS})
+	typeText(m, dir)
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if !m.SaveAs.open || m.SaveAs.input.String() != dir {
+		t.Fatalf("expected the prompt kept open with %s, got open=%v %q", dir, m.SaveAs.open, m.SaveAs.input.String())
+	}
+	if hint := m.saveAsHint(); !strings.Contains(hint, "is a directory") {
+		t.Errorf("expected the error in the hint, got %q", hint)
+	}
+
+	path := filepath.Join(dir, "in.txt")
+	typeText(m, "/in.txt")
+	if hint := m.saveAsHint(); strings.Contains(hint, "is a directory") {
+		t.Errorf("expected the error cleared by typing, got %q", hint)
+	}
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.File.FilePath != path {
+		t.Errorf("expected saved to %s, got %q", path, m.File.FilePath)
+	}
+}
+
+func TestSaveAs_RunsAfter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	m := newTestModel(t)
+	typeText(m, "draft")
+	var ran []string
+	m.saveOrAsk(func(m *Model) tea.Cmd {
+		ran = append(ran, m.File.FilePath)
+		return nil
+	})
+	typeText(m, path)
+	send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(ran) != 1 || ran[0] != path {
+		t.Errorf("expected after run once with the buffer saved to %s, got %q", path, ran)
+	}
+	if m.SaveAs.after != nil {
+		t.Errorf("expected after cleared with the prompt")
+	}
+}
+
+func TestSaveAs_WriteQuitUnnamed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "draft.txt")
+	m := newTestModel(t)
+	typeText(m, "draft")
+	if quits(execute(m, "wq")) {
+		t.Fatalf("expected :wq on an unnamed buffer to ask for a path first")
+	}
+	if !m.SaveAs.open {
+		t.Fatalf("expected the Save As prompt opened")
+	}
+	typeText(m, path)
+	if !quits(send(m, tea.KeyMsg{Type: tea.KeyEnter})) {
+		t.Errorf("expected :wq to quit once the buffer is saved")
+	}
+	if got := readFile(t, path); !strings.HasPrefix(got, "draft") {
+		t.Errorf("expected the buffer written, got %q", got)
+	}
+}
+
+func TestSaveAs_WriteQuitUnnamedKeepsOtherChanges(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other.txt")
+	m := newTestModel(t, "", other)
+	typeText(m, "draft")
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlPgDown})
+	typeText(m, "edit")
+	send(m, tea.KeyMsg{Type: tea.KeyCtrlPgUp})
+
+	execute(m, "wq")
+	typeText(m, filepath.Join(dir, "draft.txt"))
+	if quits(send(m, tea.KeyMsg{Type: tea.KeyEnter})) {
+		t.Fatalf("expected :wq not to quit with %s unsaved", other)
+	}
+	if !strings.Contains(m.StatusMessage, "unsaved changes") {
+		t.Errorf("expected the unsaved buffer reported, got %q", m.StatusMessage)
+	}
+}
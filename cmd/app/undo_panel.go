@@ -0,0 +1,82 @@
+package app
+
+import (
+	"editGo/editor"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"time"
+)
+
+// updateUndoPanel handles keys while the undo tree panel is open.
+func (m Model) updateUndoPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	branches := m.UndoStack.Branches()
+	switch {
+	case msg.Type == tea.KeyEsc, msg.Type == tea.KeyF2:
+		m.UndoPanel = false
+	case msg.Type == tea.KeyUp:
+		if m.UndoSelected < len(branches)-1 {
+			m.UndoSelected++
+		}
+	case msg.Type == tea.KeyDown:
+		if m.UndoSelected > 0 {
+			m.UndoSelected--
+		}
+	case msg.Type == tea.KeyEnter:
+		if m.UndoSelected < len(branches) {
+			m.restoreCursor(m.UndoStack.GoTo(m.Buffer, branches[m.UndoSelected].Seq))
+		}
+	case msg.String() == "-":
+		m.restoreCursor(m.UndoStack.StepBack(m.Buffer))
+	case msg.String() == "+":
+		m.restoreCursor(m.UndoStack.StepForward(m.Buffer))
+	case msg.String() == "<":
+		m.restoreCursor(m.UndoStack.Earlier(m.Buffer, time.Minute))
+	case msg.String() == ">":
+		m.restoreCursor(m.UndoStack.Later(m.Buffer, time.Minute))
+	}
+	return m, nil
+}
+
+// openUndoPanel shows the undo tree with the active branch selected.
+func (m *Model) openUndoPanel() {
+	m.UndoPanel = true
+	m.UndoSelected = 0
+	for i, b := range m.UndoStack.Branches() {
+		if b.Active {
+			m.UndoSelected = i
+		}
+	}
+}
+
+// timeTravel moves the buffer through the undo history by the duration
+// in arg, such as "30s" or "5m": back for :earlier, forward for :later.
+func (m *Model) timeTravel(arg string, earlier bool) error {
+	d, err := time.ParseDuration(arg)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("expected a positive duration such as 30s or 5m, got %q", arg)
+	}
+	m.UndoStack.Boundary()
+	travel := m.UndoStack.Later
+	if earlier {
+		travel = m.UndoStack.Earlier
+	}
+	pos, ok := travel(m.Buffer, d)
+	if !ok {
+		m.StatusMessage = "Already at the oldest change"
+		if !earlier {
+			m.StatusMessage = "Already at the newest change"
+		}
+		return nil
+	}
+	m.restoreCursor(pos, ok)
+	m.StatusMessage = fmt.Sprintf("Moved to change %d of %d", m.UndoStack.CurrentSeq(), m.UndoStack.LastSeq())
+	return nil
+}
+
+func (m *Model) restoreCursor(pos editor.CursorPointer, ok bool) {
+	if ok {
+		m.Cursor.SetPosition(pos.X, pos.Y, m.Buffer)
+		m.Highlighter.Reset()
+		m.AutoSaver.NotifyEdit()
+	}
+}
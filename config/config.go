@@ -0,0 +1,89 @@
+package config
+
+import (
+	"editGo/editor"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config holds the user's settings, read from config.json in Dir.
+type Config struct {
+	// Autosave is an autosave policy spec, see data.ParsePolicy.
+	Autosave string `json:"autosave"`
+	// KeepBackup keeps the previous version of a file as <file>.bak.
+	KeepBackup bool `json:"keepBackup"`
+	// ScrollOff is how many lines to keep visible above and below the
+	// cursor; SideScrollOff is the same for columns.
+	ScrollOff     int `json:"scrollOff"`
+	SideScrollOff int `json:"sideScrollOff"`
+	// LineNumbers is "off", "absolute", "relative" or "hybrid".
+	LineNumbers string `json:"lineNumbers"`
+	// SoftWrap folds long lines at the window edge; WordWrap breaks them
+	// between words.
+	SoftWrap bool `json:"softWrap"`
+	WordWrap bool `json:"wordWrap"`
+	// TabWidth is how many columns apart tab stops are.
+	TabWidth int `json:"tabWidth"`
+	// Theme names a built-in theme or a <name>.json or <name>.toml file in
+	// ThemeDir.
+	Theme string `json:"theme"`
+}
+
+func Default() Config {
+	return Config{
+		Autosave:      "interval:5s",
+		ScrollOff:     3,
+		SideScrollOff: 5,
+		LineNumbers:   "off",
+		TabWidth:      editor.DefaultTabWidth,
+		Theme:         "dracula",
+	}
+}
+
+// Dir returns the per-user config directory: $XDG_CONFIG_HOME/editgo or
+// the platform equivalent.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "editgo"), nil
+}
+
+// ThemeDir returns the directory user themes are read from, or "" when
+// there is no config directory.
+func ThemeDir() string {
+	dir, err := Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "themes")
+}
+
+// Load reads the user's config. Settings missing from the file keep their
+// defaults, and a missing file is not an error.
+func Load() (Config, error) {
+	dir, err := Dir()
+	if err != nil {
+		return Default(), err
+	}
+	return LoadFile(filepath.Join(dir, "config.json"))
+}
+
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return Default(), err
+	}
+	return cfg, nil
+}
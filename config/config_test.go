@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFile_Missing(t *testing.T) {
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatalf("missing config should not fail: %v", err)
+	}
+	if cfg != Default() {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoadFile_KeepsDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"keepBackup": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if !cfg.KeepBackup || cfg.Autosave != Default().Autosave {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"autosave": 5}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err == nil {
+		t.Errorf("expected an error for a malformed config")
+	}
+	if cfg != Default() {
+		t.Errorf("expected defaults on error, got %+v", cfg)
+	}
+}
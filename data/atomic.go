@@ -0,0 +1,88 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeContent writes the new file body; tests replace it to simulate a
+// failing disk.
+var writeContent = func(f *os.File, content []byte) error {
+	_, err := f.Write(content)
+	return err
+}
+
+// writeFileAtomic replaces path with content without ever leaving a
+// partly written file behind: the data goes to a temp file in the same
+// directory, is synced, given the original's mode and owner, and renamed
+// over path. With backup set, the previous version is kept as path.bak.
+func writeFileAtomic(path string, content []byte, backup bool) (err error) {
+	// Write through symlinks instead of replacing them.
+	if resolved, evalErr := filepath.EvalSymlinks(path); evalErr == nil {
+		path = resolved
+	}
+	dir := filepath.Dir(path)
+
+	mode := os.FileMode(0644)
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = writeContent(tmp, content); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if statErr == nil {
+		preserveOwner(tmp, info)
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if backup && statErr == nil {
+		if err = copyFile(path, path+".bak", mode); err != nil {
+			return err
+		}
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, content, mode)
+}
+
+// syncDir flushes the directory entry so the rename survives a crash.
+// Not every platform supports it, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
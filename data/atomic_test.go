@@ -0,0 +1,129 @@
+package data
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveAs_FailedWriteKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "keep.txt")
+	writeTestFile(t, filePath, "original\n", 0644)
+
+	fm, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	fm.Buffer.SetLines([][]rune{[]rune("replacement")})
+	fm.Buffer.SetDirty(true)
+
+	orig := writeContent
+	writeContent = func(f *os.File, content []byte) error {
+		f.Write(content[:3]) // partial write, then the disk fills up
+		return errors.New("no space left on device")
+	}
+	defer func() { writeContent = orig }()
+
+	if err := fm.Save(); err == nil {
+		t.Fatalf("expected save to fail")
+	}
+
+	got, _ := os.ReadFile(filePath)
+	if string(got) != "original\n" {
+		t.Errorf("original file damaged: %q", got)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected temp file to be cleaned up, dir has %d entries", len(entries))
+	}
+	if !fm.Buffer.IsDirty() {
+		t.Errorf("expected buffer to stay dirty after failed save")
+	}
+}
+
+func TestSaveAs_PreservesMode(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "script.sh")
+	writeTestFile(t, filePath, "echo hi\n", 0750)
+
+	fm, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	if err := fm.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("expected mode 0750, got %o", info.Mode().Perm())
+	}
+}
+
+func TestSaveAs_KeepBackup(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "notes.txt")
+	writeTestFile(t, filePath, "version 1\n", 0644)
+
+	fm, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	fm.KeepBackup = true
+	fm.Buffer.SetLines([][]rune{[]rune("version 2")})
+	if err := fm.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	backup, err := os.ReadFile(filePath + ".bak")
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	if string(backup) != "version 1\n" {
+		t.Errorf("backup content: got %q", backup)
+	}
+	current, _ := os.ReadFile(filePath)
+	if string(current) != "version 2\n" {
+		t.Errorf("saved content: got %q", current)
+	}
+}
+
+func TestSaveAs_FollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.txt")
+	link := filepath.Join(dir, "link.txt")
+	writeTestFile(t, target, "old\n", 0644)
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	fm, err := NewFile(link)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	fm.Buffer.SetLines([][]rune{[]rune("new")})
+	if err := fm.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink was replaced by a regular file")
+	}
+	got, _ := os.ReadFile(target)
+	if string(got) != "new\n" {
+		t.Errorf("target content: got %q", got)
+	}
+}
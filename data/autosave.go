@@ -1,45 +1,137 @@
 package data
 
 import (
+	"errors"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var errNoPath = errors.New("file path is empty")
+
+// DefaultSwapDelay is how long after an edit autosave writes the swap
+// file, whatever its Policy.
+const DefaultSwapDelay = 2 * time.Second
+
+// SaveResult is the outcome of one autosave of a named file.
+type SaveResult struct {
+	Path string
+	Hash string // hash of the content written, when Err is nil
+	Err  error
+	Time time.Time
+}
+
 type AutoSave struct {
-	FM       *FileManager
-	Interval time.Duration
-	Quit     chan struct{}
+	FM *FileManager
+	// Policy decides when to save; it is only used by the autosave
+	// goroutine, so set it before Start.
+	Policy Policy
+	// SwapDelay is how long after an unsaved edit the swap file is written,
+	// so every buffer has one even when the Policy rarely saves; zero
+	// leaves the swap to the saves the Policy asks for. Set it before Start.
+	SwapDelay time.Duration
+	Clock     Clock
+	Quit      chan struct{}
+	// Results receives every SaveResult; a result is dropped if the
+	// previous one hasn't been read yet. It is closed once autosave stops.
+	Results chan SaveResult
+
+	// Edits and focus losses are counted rather than queued, so a burst of
+	// typing is never dropped; wake tells the goroutine there are some.
+	edits     atomic.Int64
+	focusLost atomic.Int64
+	wake      chan struct{}
+
+	started  bool
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
+// NewAutoSave saves fm every interval; set Policy for other behaviour.
 func NewAutoSave(fm *FileManager, interval time.Duration) *AutoSave {
 	return &AutoSave{
-		FM:       fm,
-		Interval: interval,
-		Quit:     make(chan struct{}),
+		FM:        fm,
+		Policy:    IntervalPolicy{Every: interval},
+		SwapDelay: DefaultSwapDelay,
+		Clock:     realClock{},
+		Quit:      make(chan struct{}),
+		Results:   make(chan SaveResult, 1),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// NotifyEdit tells the policy the buffer changed. It never blocks; edits
+// made while autosave is busy are all handed over once it is free.
+func (a *AutoSave) NotifyEdit() {
+	a.notify(&a.edits)
+}
+
+// NotifyFocusLost tells the policy the terminal lost focus.
+func (a *AutoSave) NotifyFocusLost() {
+	a.notify(&a.focusLost)
+}
+
+func (a *AutoSave) notify(count *atomic.Int64) {
+	count.Add(1)
+	select {
+	case a.wake <- struct{}{}:
+	default: // already woken; the count carries this one too
 	}
 }
 
+// Start runs autosave on its own goroutine. It only reads the buffer
+// through snapshots, so the editor can keep changing it meanwhile.
 func (a *AutoSave) Start() {
-	if a.FM.FilePath == "" {
-		log.Println("auto save file not exist")
+	if a.started {
 		return
 	}
+	a.started = true
+	a.done = make(chan struct{})
+	if a.FM.FilePath == "" {
+		log.Println("auto save file not exist, keeping swap file only")
+	}
 	go func() {
-		ticker := time.NewTicker(a.Interval)
-		defer ticker.Stop()
+		defer close(a.done)
+		defer close(a.Results)
+		var timer, swapTimer <-chan time.Time
+		// handle passes the policy n triggers of kind t, then arms the
+		// last timer it asked for and saves once if any asked to.
+		handle := func(t Trigger, n int64) {
+			var save bool
+			var next time.Duration
+			for range n {
+				s, d := a.Policy.Handle(t)
+				save = save || s
+				if d > 0 {
+					next = d
+				}
+			}
+			if next > 0 {
+				timer = a.Clock.After(next)
+			}
+			if save {
+				a.tick()
+			}
+		}
 
 		log.Println("auto save file started")
+		handle(TriggerStart, 1)
 		for {
 			select {
-			case <-ticker.C:
-				if a.FM.Buffer.IsDirty() {
-					err := a.FM.Save()
-					if err != nil {
-						log.Println("auto save file err:", err)
-					} else {
-						log.Println("auto save file done")
-					}
+			case <-timer:
+				timer = nil
+				handle(TriggerTimer, 1)
+			case <-swapTimer:
+				swapTimer = nil
+				a.writeSwap()
+			case <-a.wake:
+				edits := a.edits.Swap(0)
+				if edits > 0 && swapTimer == nil && a.SwapDelay > 0 {
+					swapTimer = a.Clock.After(a.SwapDelay)
 				}
+				handle(TriggerEdit, edits)
+				handle(TriggerFocusLost, a.focusLost.Swap(0))
 			case <-a.Quit:
 				log.Println("auto save file quit")
 				return
@@ -48,6 +140,58 @@ func (a *AutoSave) Start() {
 	}()
 }
 
+// tick mirrors a dirty buffer to its swap file and then saves it if it
+// has a name. The swap stays until the changes are saved for real or
+// discarded, so a failed or later-undone autosave loses nothing.
+func (a *AutoSave) tick() {
+	if !a.writeSwap() {
+		return
+	}
+	path, hash, err := a.FM.saveChecked()
+	if path == "" {
+		return
+	}
+	a.report(SaveResult{Path: path, Hash: hash, Err: err, Time: a.Clock.Now()})
+	if err != nil {
+		log.Println("auto save file err:", err)
+		return
+	}
+	log.Println("auto save file done")
+}
+
+// writeSwap mirrors the buffer to its swap file if it is dirty, and
+// reports whether it was.
+func (a *AutoSave) writeSwap() bool {
+	if !a.FM.Buffer.IsDirty() {
+		return false
+	}
+	lines, _ := a.FM.Buffer.Snapshot(false)
+	if err := a.FM.Swap.Write(a.FM.path(), lines); err != nil {
+		log.Println("swap file err:", err)
+	}
+	return true
+}
+
+func (a *AutoSave) report(result SaveResult) {
+	select {
+	case a.Results <- result:
+	default:
+	}
+}
+
+// Stop ends autosaving and waits for an autosave in progress to finish.
+// The swap file is left for the editor to remove once it knows whether
+// the changes were kept. It is safe to call more than once.
 func (a *AutoSave) Stop() {
-	close(a.Quit)
+	a.stopOnce.Do(func() { close(a.Quit) })
+	if a.done != nil {
+		<-a.done
+	}
+}
+
+// path is FilePath for the autosave goroutine.
+func (fm *FileManager) path() string {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return fm.FilePath
 }
@@ -1,20 +1,101 @@
 package data
 
 import (
+	"editGo/editor"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
+// fakeClock is a Clock that only moves when the test calls Advance.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+	armed  chan struct{}
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0), armed: make(chan struct{}, 100)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), c: ch})
+	c.mu.Unlock()
+	c.armed <- struct{}{}
+	return ch
+}
+
+// Advance moves the clock on by d and fires the timers that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.c <- c.now
+	}
+	c.timers = pending
+}
+
+// waitArmed blocks until autosave has asked for another timer.
+func (c *fakeClock) waitArmed(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.armed:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("autosave never armed its timer")
+	}
+}
+
+func newTestAutoSave(fm *FileManager, policy Policy) (*AutoSave, *fakeClock) {
+	clock := newFakeClock()
+	auto := NewAutoSave(fm, time.Hour)
+	auto.Policy = policy
+	auto.SwapDelay = 0 // only the policy arms timers
+	auto.Clock = clock
+	return auto, clock
+}
+
+func waitResult(t *testing.T, auto *AutoSave) SaveResult {
+	t.Helper()
+	select {
+	case result := <-auto.Results:
+		return result
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no autosave result delivered")
+	}
+	return SaveResult{}
+}
+
 func TestAutoSave_DoesNotStartForEmptyPath(t *testing.T) {
 	fm, _ := NewEmptyFile("")
-	auto := NewAutoSave(fm, 100*time.Millisecond)
+	auto, clock := newTestAutoSave(fm, IntervalPolicy{Every: 100 * time.Millisecond})
 	auto.Start()
 
 	// Should not autosave because file path is empty
-	time.Sleep(200 * time.Millisecond)
+	clock.waitArmed(t)
+	clock.Advance(200 * time.Millisecond)
 	// No panic, no effect = success
 	auto.Stop()
 }
@@ -29,14 +110,16 @@ func TestAutoSave_SavesWhenDirty(t *testing.T) {
 		t.Fatalf("failed to create buffer: %v", err)
 	}
 	fm.FilePath = filePath
-	fm.Buffer.Lines = [][]rune{[]rune("first")}
+	fm.Buffer.SetLines([][]rune{[]rune("first")})
 	fm.Buffer.SetDirty(true) // trigger autosave
 
-	auto := NewAutoSave(fm, 200*time.Millisecond)
+	auto, clock := newTestAutoSave(fm, IntervalPolicy{Every: 200 * time.Millisecond})
 	auto.Start()
 
-	// Wait enough time for autosave to trigger
-	time.Sleep(300 * time.Millisecond)
+	// Move past the interval so autosave triggers
+	clock.waitArmed(t)
+	clock.Advance(300 * time.Millisecond)
+	waitResult(t, auto)
 	auto.Stop()
 
 	// Check that file exists and content is saved
@@ -58,18 +141,193 @@ func TestAutoSave_Stop(t *testing.T) {
 
 	fm, _ := NewEmptyFile("")
 	fm.FilePath = filePath
-	fm.Buffer.Lines = [][]rune{[]rune("stop test")}
+	fm.Buffer.SetLines([][]rune{[]rune("stop test")})
 	fm.Buffer.SetDirty(true)
 
-	auto := NewAutoSave(fm, 100*time.Millisecond)
+	auto, clock := newTestAutoSave(fm, IntervalPolicy{Every: 100 * time.Millisecond})
 	auto.Start()
 	auto.Stop()
 
-	// Wait to confirm no goroutine is running after stop
-	time.Sleep(200 * time.Millisecond)
+	// Timers firing after stop must not save
+	clock.Advance(200 * time.Millisecond)
 
 	// Dirty buffer should still be dirty because autosave stopped early
 	if !fm.Buffer.IsDirty() {
 		t.Errorf("expected buffer to remain dirty after stop")
 	}
 }
+
+func TestAutoSave_StopIsIdempotent(t *testing.T) {
+	fm, _ := NewEmptyFile("")
+	auto := NewAutoSave(fm, time.Hour)
+	auto.Stop() // never started
+	auto.Start()
+	auto.Stop()
+	auto.Stop()
+}
+
+func TestAutoSave_ReportsResults(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "report.txt")
+	fm, _ := NewEmptyFile(filePath)
+	fm.Buffer.InsertRune(0, 0, 'x')
+
+	auto, clock := newTestAutoSave(fm, IntervalPolicy{Every: 10 * time.Millisecond})
+	auto.Start()
+	defer auto.Stop()
+
+	clock.waitArmed(t)
+	clock.Advance(10 * time.Millisecond)
+	result := waitResult(t, auto)
+	if result.Err != nil || result.Path != filePath || result.Hash == "" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if !result.Time.Equal(clock.Now()) {
+		t.Errorf("expected result time from the clock, got %v", result.Time)
+	}
+}
+
+func TestAutoSave_IdleWaitsForPause(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "idle.txt")
+	fm, _ := NewEmptyFile(filePath)
+	cursor := editor.NewCursor(0, 0)
+	auto, clock := newTestAutoSave(fm, IdlePolicy{After: 2 * time.Second})
+	auto.Start()
+	defer auto.Stop()
+
+	// typing every second never leaves two idle seconds
+	for _, r := range "abc" {
+		fm.History.Apply(fm.Buffer, cursor, editor.EditAction{
+			Line: 0, Col: cursor.X, Text: []rune{r}, Action: editor.ActionInsert,
+		})
+		auto.NotifyEdit()
+		clock.waitArmed(t)
+		clock.Advance(time.Second)
+	}
+	if _, err := os.Stat(filePath); err == nil {
+		t.Fatalf("saved while still typing")
+	}
+
+	clock.Advance(time.Second)
+	if result := waitResult(t, auto); result.Err != nil {
+		t.Fatalf("autosave failed: %v", result.Err)
+	}
+	content, _ := os.ReadFile(filePath)
+	if string(content) != "abc\n" {
+		t.Errorf("expected %q saved, got %q", "abc\n", content)
+	}
+}
+
+func TestAutoSave_FocusLost(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "focus.txt")
+	fm, _ := NewEmptyFile(filePath)
+	fm.Buffer.InsertRune(0, 0, 'x')
+	auto, _ := newTestAutoSave(fm, FocusPolicy{})
+	auto.Start()
+	defer auto.Stop()
+
+	auto.NotifyEdit()
+	auto.NotifyFocusLost()
+	if result := waitResult(t, auto); result.Err != nil || result.Path != filePath {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if fm.Buffer.IsDirty() {
+		t.Errorf("expected buffer clean after saving on focus loss")
+	}
+}
+
+func TestAutoSave_EditsPolicyCountsBursts(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "burst.txt")
+	fm, _ := NewEmptyFile(filePath)
+	fm.Buffer.InsertRune(0, 0, 'x')
+	auto, _ := newTestAutoSave(fm, &EditsPolicy{N: 100})
+	defer auto.Stop()
+
+	// more edits than autosave could ever have queued, all before it runs
+	for range 100 {
+		auto.NotifyEdit()
+	}
+	auto.Start()
+	if result := waitResult(t, auto); result.Err != nil || result.Path != filePath {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestAutoSave_SwapWithoutSaving(t *testing.T) {
+	fm, _ := NewEmptyFile("")
+	auto, clock := newTestAutoSave(fm, FocusPolicy{})
+	auto.SwapDelay = time.Second
+	auto.Start()
+	defer auto.Stop()
+	swap, err := fm.Swap.swapPath("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fm.Buffer.InsertRune(0, 0, 'x')
+	auto.NotifyEdit()
+	clock.waitArmed(t)
+	if _, err := os.Stat(swap); err == nil {
+		t.Fatalf("swap written before its delay")
+	}
+	clock.Advance(time.Second)
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(time.Millisecond) {
+		if _, err := os.Stat(swap); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a swap file for the unnamed buffer without any save")
+		}
+	}
+	fm.Swap.Remove()
+}
+
+// TestAutoSave_EditorReadsPath reads FilePath and checks the disk the way
+// the editor does while autosave keeps saving; run with -race.
+func TestAutoSave_EditorReadsPath(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "path.txt")
+	fm, _ := NewEmptyFile(filePath)
+	auto := NewAutoSave(fm, time.Millisecond)
+	auto.Start()
+	defer auto.Stop()
+	for range 200 {
+		fm.Buffer.InsertRune(0, 0, 'a')
+		if fm.FilePath != filePath {
+			t.Fatalf("expected the path left alone, got %s", fm.FilePath)
+		}
+		if _, err := fm.CheckExternal(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+}
+
+// TestAutoSave_ConcurrentEdits keeps typing while autosave runs; run with
+// -race to check that autosave only reads the buffer safely.
+func TestAutoSave_ConcurrentEdits(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "busy.txt")
+	fm, _ := NewEmptyFile(filePath)
+	cursor := editor.NewCursor(0, 0)
+
+	auto := NewAutoSave(fm, time.Millisecond)
+	auto.Start()
+	for i := 0; i < 2000; i++ {
+		if i%100 == 99 {
+			fm.History.Apply(fm.Buffer, cursor, editor.EditAction{
+				Line: cursor.Y, Col: cursor.X, Text: []rune{'\n'}, Action: editor.ActionInsert,
+			})
+			continue
+		}
+		fm.History.Apply(fm.Buffer, cursor, editor.EditAction{
+			Line: cursor.Y, Col: cursor.X, Text: []rune{'a'}, Action: editor.ActionInsert,
+		})
+	}
+	auto.Stop()
+
+	if err := fm.Save(); err != nil {
+		t.Fatalf("final save failed: %v", err)
+	}
+	content, _ := os.ReadFile(filePath)
+	if got := strings.Count(string(content), "a"); got != 1980 {
+		t.Errorf("expected 1980 runes saved, got %d", got)
+	}
+}
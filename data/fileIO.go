@@ -1,37 +1,64 @@
 package data
 
 import (
-	"bufio"
 	"editGo/editor"
-	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
+// FileManager ties a buffer to its file. The editor goroutine owns it;
+// autosave reaches in from its own goroutine only through methods that
+// take mu, which guards FilePath, Disk and the format fields. Only the
+// editor goroutine changes FilePath, in SaveAs, so it reads it directly
+// while autosave goes through path.
 type FileManager struct {
+	mu sync.Mutex
+	// saving is held for a whole save, so saves and reloads don't
+	// overlap, while mu is only taken around the bookkeeping and not
+	// while the file is written and synced.
+	saving sync.Mutex
+
 	FilePath string
 	Buffer   *editor.TextBuffer
+	History  *editor.UndoManager
+
+	// On-disk format, detected at load and reproduced on save.
+	LineEnding      LineEnding
+	TrailingNewline bool
+	BOM             bool
+
+	// KeepBackup keeps the previous version as <file>.bak on every save.
+	KeepBackup bool
+
+	// Disk is the version of FilePath last loaded or saved, used to
+	// notice changes made by other programs.
+	Disk Fingerprint
+
+	// Swap mirrors the unsaved buffer for crash recovery. Autosave writes
+	// it; it is removed by a save or reload, or by the editor when the
+	// changes are discarded.
+	Swap *SwapFile
 }
 
 func NewFile(filePath string) (*FileManager, error) {
-	file, err := os.Open(filePath)
+	disk, content, err := readFingerprint(filePath)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	lines := [][]rune{}
-	for scanner.Scan() {
-		lines = append(lines, []rune(scanner.Text()))
+	if !disk.exists() {
+		return nil, &os.PathError{Op: "open", Path: filePath, Err: os.ErrNotExist}
 	}
-	if len(lines) == 0 {
-		lines = [][]rune{{}} // ensure buffer isn't empty
-	}
-	buffer := editor.NewTextBufferWithLines(lines)
 	fm := &FileManager{
 		FilePath: filePath,
-		Buffer:   buffer,
+		Disk:     disk,
+		Swap:     NewSwapFile(),
 	}
+	lines := fm.decodeContent(content)
+	fm.Buffer = editor.NewTextBufferWithLines(lines)
+	fm.History = loadHistory(filePath, hashContent(content))
+	fm.History.OnSet = fm.restoreSetting
 	return fm, nil
 }
 
@@ -40,7 +67,13 @@ func NewEmptyFile(filePath string) (*FileManager, error) {
 	fm := &FileManager{
 		Buffer:   buffer,
 		FilePath: filePath,
+		History:  editor.NewUndoManager(),
+		Swap:     NewSwapFile(),
+
+		LineEnding:      LF,
+		TrailingNewline: true,
 	}
+	fm.History.OnSet = fm.restoreSetting
 	return fm, nil
 }
 
@@ -48,33 +81,98 @@ func (fm *FileManager) IsNewFile() bool {
 	return fm.FilePath == ""
 }
 
+// Save writes the buffer to FilePath, refusing with ErrExternallyModified
+// if another program changed the file since it was loaded or saved.
 func (fm *FileManager) Save() error {
-	if fm.FilePath == "" {
-		return fmt.Errorf("file path is empty")
+	_, hash, err := fm.saveChecked()
+	if err != nil {
+		return err
 	}
-	return fm.SaveAs(fm.FilePath)
+	fm.SaveHistory(hash)
+	fm.Swap.Remove()
+	return nil
 }
 
 func (fm *FileManager) SaveAs(filePath string) error {
-	if err := ensurePath(filePath); err != nil {
+	fm.saving.Lock()
+	defer fm.saving.Unlock()
+	hash, err := fm.saveTo(filePath)
+	if err != nil {
 		return err
 	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.FilePath = filePath
+	fm.persistHistory(hash)
+	fm.Swap.Remove()
+	return nil
+}
 
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
+// SaveHistory stores the undo history for the file content with the
+// given hash. Autosave leaves this to the editor goroutine, which owns
+// History, and it must only be called while the buffer still matches
+// that content.
+func (fm *FileManager) SaveHistory(hash string) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.persistHistory(hash)
+}
+
+func (fm *FileManager) persistHistory(hash string) {
+	if fm.History == nil || fm.FilePath == "" {
+		return
 	}
-	defer file.Close()
-	for _, line := range fm.Buffer.Lines {
-		_, err := file.WriteString(string(line) + "\n")
-		if err != nil {
-			return err
-		}
+	if err := saveHistory(fm.FilePath, hash, fm.History); err != nil {
+		log.Println("save undo history err:", err)
 	}
-	fm.FilePath = filePath
-	fm.Buffer.SetDirty(false)
+}
 
-	return nil
+// saveChecked is Save without the history, and also returns the path it
+// saved to.
+func (fm *FileManager) saveChecked() (path, hash string, err error) {
+	fm.saving.Lock()
+	defer fm.saving.Unlock()
+	fm.mu.Lock()
+	path = fm.FilePath
+	changed, err := fm.checkExternal()
+	fm.mu.Unlock()
+	switch {
+	case path == "":
+		return "", "", errNoPath
+	case err != nil:
+		return path, "", err
+	case changed:
+		return path, "", ErrExternallyModified
+	}
+	hash, err = fm.saveTo(path)
+	return path, hash, err
+}
+
+// saveTo writes a snapshot of the buffer to filePath and returns the hash
+// of what was written; fm.saving must be held.
+func (fm *FileManager) saveTo(filePath string) (string, error) {
+	if err := ensurePath(filePath); err != nil {
+		return "", err
+	}
+
+	fm.mu.Lock()
+	lines, wasDirty := fm.Buffer.Snapshot(true)
+	content := fm.encodeContent(lines)
+	backup := fm.KeepBackup
+	fm.mu.Unlock()
+	if err := writeFileAtomic(filePath, content, backup); err != nil {
+		if wasDirty {
+			fm.Buffer.SetDirty(true)
+		}
+		return "", err
+	}
+	hash := hashContent(content)
+	if info, err := os.Stat(filePath); err == nil {
+		fm.mu.Lock()
+		fm.Disk = Fingerprint{ModTime: info.ModTime(), Size: info.Size(), Hash: hash}
+		fm.mu.Unlock()
+	}
+	return hash, nil
 }
 
 func ensurePath(path string) error {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -23,10 +24,10 @@ func TestSaveAsAndLoad(t *testing.T) {
 	filePath := filepath.Join(tmpDir, "test.txt")
 
 	fm, _ := NewEmptyFile("")
-	fm.Buffer.Lines = [][]rune{
+	fm.Buffer.SetLines([][]rune{
 		[]rune("hello"),
 		[]rune("world"),
-	}
+	})
 
 	err := fm.SaveAs(filePath)
 	if err != nil {
@@ -67,3 +68,37 @@ func getStringLines(buf *editor.TextBuffer) []string {
 	}
 	return lines
 }
+
+func TestLoadVeryLongLine(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "bundle.min.js")
+	line := strings.Repeat("var a=1;", 512*1024) // 4MB, far past bufio.Scanner's 64KB limit
+	if err := os.WriteFile(filePath, []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fm, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	if fm.Buffer.LineCount() != 1 || len(fm.Buffer.GetLine(0)) != len(line) {
+		t.Fatalf("long line truncated: %d lines, first has %d runes", fm.Buffer.LineCount(), len(fm.Buffer.GetLine(0)))
+	}
+
+	if err := fm.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, _ := os.ReadFile(filePath)
+	if string(saved) != line+"\n" {
+		t.Errorf("saved content differs from original (%d vs %d bytes)", len(saved), len(line)+1)
+	}
+}
+
+func TestLoadReportsReadError(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFile(dir); err == nil {
+		t.Errorf("expected error reading a directory")
+	}
+	if _, err := NewFile(filepath.Join(dir, "missing.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
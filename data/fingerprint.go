@@ -0,0 +1,131 @@
+package data
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+var ErrExternallyModified = errors.New("file was modified outside the editor")
+
+// Fingerprint identifies the version of a file on disk. The zero value
+// means the file did not exist.
+type Fingerprint struct {
+	ModTime time.Time
+	Size    int64
+	Hash    string
+}
+
+func (f Fingerprint) exists() bool {
+	return f.Hash != ""
+}
+
+// readFingerprint stats path and hashes its content. A missing file gives
+// the zero Fingerprint.
+func readFingerprint(path string) (Fingerprint, []byte, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Fingerprint{}, nil, nil
+	}
+	if err != nil {
+		return Fingerprint{}, nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Fingerprint{}, nil, err
+	}
+	return Fingerprint{ModTime: info.ModTime(), Size: info.Size(), Hash: hashContent(content)}, content, nil
+}
+
+// CheckExternal reports whether the file on disk differs from the version
+// last loaded or saved. It only hashes the file when its size or
+// modification time changed.
+func (fm *FileManager) CheckExternal() (bool, error) {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return fm.checkExternal()
+}
+
+func (fm *FileManager) checkExternal() (bool, error) {
+	if fm.FilePath == "" {
+		return false, nil
+	}
+	info, err := os.Stat(fm.FilePath)
+	if os.IsNotExist(err) {
+		return fm.Disk.exists(), nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if fm.Disk.exists() && info.Size() == fm.Disk.Size && info.ModTime().Equal(fm.Disk.ModTime) {
+		return false, nil
+	}
+
+	current, _, err := readFingerprint(fm.FilePath)
+	if err != nil {
+		return false, err
+	}
+	if current.Hash == fm.Disk.Hash {
+		fm.Disk = current // touched, not changed
+		return false, nil
+	}
+	return true, nil
+}
+
+// Deleted reports whether the file was on disk when last loaded or saved
+// and is gone now.
+func (fm *FileManager) Deleted() bool {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if fm.FilePath == "" || !fm.Disk.exists() {
+		return false
+	}
+	_, err := os.Stat(fm.FilePath)
+	return os.IsNotExist(err)
+}
+
+// AcceptExternal keeps the buffer as it is and lets the next Save
+// overwrite whatever is on disk now, or create the file again if it was
+// deleted.
+func (fm *FileManager) AcceptExternal() error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	current, _, err := readFingerprint(fm.FilePath)
+	if err != nil {
+		return err
+	}
+	fm.Disk = current
+	fm.Buffer.SetDirty(true)
+	return nil
+}
+
+// ReadDisk returns the lines currently stored in the file.
+func (fm *FileManager) ReadDisk() ([][]rune, error) {
+	content, err := os.ReadFile(fm.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return (&FileManager{}).decodeContent(content), nil
+}
+
+// Reload replaces the buffer with the file on disk. The previous content
+// stays reachable through undo.
+func (fm *FileManager) Reload() error {
+	fm.saving.Lock()
+	defer fm.saving.Unlock()
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	current, content, err := readFingerprint(fm.FilePath)
+	if err != nil {
+		return err
+	}
+	if !current.exists() {
+		return os.ErrNotExist
+	}
+	fm.History.Push(fm.Buffer)
+	fm.Buffer.SetLines(fm.decodeContent(content))
+	fm.Disk = current
+	fm.Buffer.SetDirty(false)
+	fm.Swap.Remove()
+	return nil
+}
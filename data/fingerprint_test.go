@@ -0,0 +1,115 @@
+package data
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// rewrite changes the file behind the editor's back, with a new mtime so
+// the change is visible even on filesystems with coarse timestamps.
+func rewrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExternal_DetectsChange(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "gen.go")
+	writeTestFile(t, filePath, "package gen\n", 0644)
+	fm, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+
+	if changed, _ := fm.CheckExternal(); changed {
+		t.Errorf("expected unchanged file right after load")
+	}
+
+	rewrite(t, filePath, "package gen // regenerated\n")
+	if changed, err := fm.CheckExternal(); err != nil || !changed {
+		t.Errorf("expected external change, got %v, %v", changed, err)
+	}
+}
+
+func TestExternal_TouchIsNotAChange(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "same.txt")
+	writeTestFile(t, filePath, "same\n", 0644)
+	fm, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+
+	rewrite(t, filePath, "same\n")
+	if changed, _ := fm.CheckExternal(); changed {
+		t.Errorf("identical content must not count as a change")
+	}
+}
+
+func TestExternal_SaveRefusesToClobber(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "shared.txt")
+	writeTestFile(t, filePath, "mine\n", 0644)
+	fm, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	fm.Buffer.SetLines([][]rune{[]rune("my edit")})
+	fm.Buffer.SetDirty(true)
+
+	rewrite(t, filePath, "theirs\n")
+
+	auto := NewAutoSave(fm, time.Hour)
+	auto.tick()
+	got, _ := os.ReadFile(filePath)
+	if string(got) != "theirs\n" {
+		t.Fatalf("autosave clobbered external change: %q", got)
+	}
+	if err := fm.Save(); !errors.Is(err, ErrExternallyModified) {
+		t.Errorf("expected ErrExternallyModified, got %v", err)
+	}
+	fm.Swap.Remove()
+
+	if err := fm.AcceptExternal(); err != nil {
+		t.Fatalf("AcceptExternal failed: %v", err)
+	}
+	if err := fm.Save(); err != nil {
+		t.Fatalf("Save after accepting failed: %v", err)
+	}
+	got, _ = os.ReadFile(filePath)
+	if string(got) != "my edit\n" {
+		t.Errorf("expected our content after keep-mine, got %q", got)
+	}
+}
+
+func TestExternal_Reload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "reload.txt")
+	writeTestFile(t, filePath, "old\n", 0644)
+	fm, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+
+	rewrite(t, filePath, "new\nlines\n")
+	if err := fm.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got := getStringLines(fm.Buffer); !reflect.DeepEqual(got, []string{"new", "lines"}) {
+		t.Errorf("reloaded lines: got %q", got)
+	}
+	if changed, _ := fm.CheckExternal(); changed {
+		t.Errorf("expected no pending change after reload")
+	}
+
+	fm.History.Undo(fm.Buffer)
+	if got := getStringLines(fm.Buffer); !reflect.DeepEqual(got, []string{"old"}) {
+		t.Errorf("undo of reload: got %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"crypto/sha256"
+	"editGo/editor"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// historyFile is the sidecar written next to each save so undo history
+// survives restarts. It only applies while the file still has ContentHash.
+type historyFile struct {
+	Path        string             `json:"path"`
+	ContentHash string             `json:"contentHash"`
+	History     editor.UndoHistory `json:"history"`
+}
+
+// StateDir returns the per-user directory for editor state such as undo
+// history: $XDG_STATE_HOME/editgo, or ~/.local/state/editgo.
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "editgo"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "editgo"), nil
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// historyPath returns the sidecar location for filePath, keyed by its
+// absolute path.
+func historyPath(filePath string) (string, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "undo", hashContent([]byte(abs))[:32]+".json"), nil
+}
+
+func saveHistory(filePath, contentHash string, um *editor.UndoManager) error {
+	path, err := historyPath(filePath)
+	if err != nil {
+		return err
+	}
+	abs, _ := filepath.Abs(filePath)
+	data, err := json.Marshal(historyFile{
+		Path:        abs,
+		ContentHash: contentHash,
+		History:     um.History(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := ensurePath(path); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// loadHistory returns the saved undo history for filePath if it was
+// written for content with contentHash, or a fresh UndoManager otherwise.
+func loadHistory(filePath, contentHash string) *editor.UndoManager {
+	path, err := historyPath(filePath)
+	if err != nil {
+		return editor.NewUndoManager()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return editor.NewUndoManager()
+	}
+	var hf historyFile
+	if err := json.Unmarshal(data, &hf); err != nil || hf.ContentHash != contentHash {
+		return editor.NewUndoManager()
+	}
+	um, err := editor.NewUndoManagerFromHistory(hf.History)
+	if err != nil {
+		return editor.NewUndoManager()
+	}
+	return um
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"editGo/editor"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestMain keeps undo history written by tests out of the real state dir.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "editgo-state")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_STATE_HOME", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestHistory_SurvivesReload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "notes.txt")
+	fm, _ := NewEmptyFile("")
+	cursor := editor.NewCursor(0, 0)
+	for _, r := range "hi" {
+		fm.History.Apply(fm.Buffer, cursor, editor.EditAction{
+			Line: cursor.Y, Col: cursor.X, Text: []rune{r}, Action: editor.ActionInsert,
+		})
+	}
+	if err := fm.SaveAs(filePath); err != nil {
+		t.Fatalf("SaveAs failed: %v", err)
+	}
+
+	loaded, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	if loaded.History.CurrentSeq() != fm.History.CurrentSeq() {
+		t.Fatalf("expected history at state %d, got %d", fm.History.CurrentSeq(), loaded.History.CurrentSeq())
+	}
+
+	loaded.History.Undo(loaded.Buffer)
+	if got := getStringLines(loaded.Buffer); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("undo after reload failed, got %v", got)
+	}
+}
+
+func TestHistory_DiscardedWhenFileChanged(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "notes.txt")
+	fm, _ := NewEmptyFile("")
+	fm.History.Apply(fm.Buffer, editor.NewCursor(0, 0), editor.EditAction{
+		Text: []rune("x"), Action: editor.ActionInsert,
+	})
+	if err := fm.SaveAs(filePath); err != nil {
+		t.Fatalf("SaveAs failed: %v", err)
+	}
+
+	// Someone else rewrites the file between sessions.
+	if err := os.WriteFile(filePath, []byte("other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+	if loaded.History.LastSeq() != 0 {
+		t.Errorf("expected fresh history for changed file, got %d states", loaded.History.LastSeq())
+	}
+}
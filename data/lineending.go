@@ -0,0 +1,208 @@
+package data
+
+import (
+	"bytes"
+	"editGo/editor"
+	"fmt"
+	"strings"
+)
+
+type LineEnding int
+
+const (
+	LF LineEnding = iota
+	CRLF
+	CR
+	// Mixed files are split on '\n' only and keep any '\r' as part of the
+	// line, so they are written back byte for byte.
+	Mixed
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+func (le LineEnding) String() string {
+	switch le {
+	case CRLF:
+		return "CRLF"
+	case CR:
+		return "CR"
+	case Mixed:
+		return "Mixed"
+	default:
+		return "LF"
+	}
+}
+
+// Sequence returns the bytes written between lines.
+func (le LineEnding) Sequence() string {
+	switch le {
+	case CRLF:
+		return "\r\n"
+	case CR:
+		return "\r"
+	default:
+		return "\n"
+	}
+}
+
+// ParseLineEnding accepts "lf"/"unix", "crlf"/"dos" and "cr"/"mac".
+func ParseLineEnding(name string) (LineEnding, error) {
+	switch strings.ToLower(name) {
+	case "lf", "unix":
+		return LF, nil
+	case "crlf", "dos":
+		return CRLF, nil
+	case "cr", "mac":
+		return CR, nil
+	}
+	return LF, fmt.Errorf("unknown line ending %q", name)
+}
+
+// detectLineEnding reports the style used by content; files without any
+// line break count as LF.
+func detectLineEnding(content []byte) LineEnding {
+	var crlf, lf, cr int
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '\r':
+			if i+1 < len(content) && content[i+1] == '\n' {
+				crlf++
+				i++
+			} else {
+				cr++
+			}
+		case '\n':
+			lf++
+		}
+	}
+	kinds := 0
+	style := LF
+	for _, k := range []struct {
+		count int
+		style LineEnding
+	}{{lf, LF}, {crlf, CRLF}, {cr, CR}} {
+		if k.count > 0 {
+			kinds++
+			style = k.style
+		}
+	}
+	if kinds > 1 {
+		return Mixed
+	}
+	return style
+}
+
+// decodeContent splits raw file content into buffer lines and remembers
+// the BOM, line ending and trailing newline so encodeContent can restore
+// them.
+func (fm *FileManager) decodeContent(content []byte) [][]rune {
+	fm.BOM = bytes.HasPrefix(content, utf8BOM)
+	content = bytes.TrimPrefix(content, utf8BOM)
+	fm.LineEnding = detectLineEnding(content)
+
+	sep := []byte(fm.LineEnding.Sequence())
+	fm.TrailingNewline = bytes.HasSuffix(content, sep)
+	if fm.TrailingNewline {
+		content = content[:len(content)-len(sep)]
+	}
+
+	lines := [][]rune{}
+	for _, line := range bytes.Split(content, sep) {
+		lines = append(lines, []rune(string(line)))
+	}
+	return lines
+}
+
+// encodeContent is the inverse of decodeContent.
+func (fm *FileManager) encodeContent(lines [][]rune) []byte {
+	var out bytes.Buffer
+	if fm.BOM {
+		out.Write(utf8BOM)
+	}
+	sep := fm.LineEnding.Sequence()
+	for i, line := range lines {
+		out.WriteString(string(line))
+		if i < len(lines)-1 || fm.TrailingNewline {
+			out.WriteString(sep)
+		}
+	}
+	return out.Bytes()
+}
+
+// FormatInfo describes the on-disk format for the status bar, e.g.
+// "CRLF BOM" or "LF noeol".
+func (fm *FileManager) FormatInfo() string {
+	info := fm.LineEnding.String()
+	if fm.BOM {
+		info += " BOM"
+	}
+	if !fm.TrailingNewline {
+		info += " noeol"
+	}
+	return info
+}
+
+// ConvertLineEnding switches the file to le as one undo step. Converting
+// a Mixed file also removes the '\r' kept inside its lines. The cursor is
+// left where it was, or as close as the changed lines allow.
+func (fm *FileManager) ConvertLineEnding(le LineEnding, cursor *editor.CursorPointer) error {
+	if le == Mixed {
+		return fmt.Errorf("cannot convert to mixed line endings")
+	}
+	if le == fm.LineEnding {
+		return nil
+	}
+	saved := *cursor
+	fm.History.Boundary()
+	fm.History.BeginGroup()
+	fm.History.Apply(fm.Buffer, cursor, editor.EditAction{
+		Action: editor.ActionSet, Name: settingLineEnding,
+		Old: []rune(fm.LineEnding.String()), Text: []rune(le.String()),
+	})
+	if fm.LineEnding == Mixed {
+		for y := 0; y < fm.Buffer.LineCount(); y++ {
+			line := fm.Buffer.GetLine(y)
+			for x, r := range line {
+				if r != '\r' {
+					continue
+				}
+				fm.History.Apply(fm.Buffer, cursor, editor.EditAction{
+					Line: y, Col: x, Text: []rune{'\r'}, Action: editor.ActionDelete,
+				})
+				if x < len(line)-1 { // a lone CR inside the line breaks it
+					fm.History.Apply(fm.Buffer, cursor, editor.EditAction{
+						Line: y, Col: x, Text: []rune{'\n'}, Action: editor.ActionInsert,
+					})
+				}
+				break
+			}
+		}
+		cursor.SetPosition(saved.X, saved.Y, fm.Buffer)
+	}
+	fm.History.EndGroup()
+	fm.setLineEnding(le)
+	fm.Buffer.SetDirty(true)
+	return nil
+}
+
+// settingLineEnding names the line ending in undo history.
+const settingLineEnding = "lineEnding"
+
+func (fm *FileManager) setLineEnding(le LineEnding) {
+	fm.mu.Lock()
+	fm.LineEnding = le
+	fm.mu.Unlock()
+}
+
+// restoreSetting is the History's OnSet: it puts back a setting that undo
+// or redo passed.
+func (fm *FileManager) restoreSetting(name, value string) {
+	if name != settingLineEnding {
+		return
+	}
+	le := Mixed
+	if value != Mixed.String() {
+		le, _ = ParseLineEnding(value)
+	}
+	fm.setLineEnding(le)
+}
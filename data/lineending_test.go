@@ -0,0 +1,130 @@
+package data
+
+import (
+	"bytes"
+	"editGo/editor"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLineEnding_RoundTrip(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		lines   []string
+		info    string
+	}{
+		{"lf", "a\nb\n", []string{"a", "b"}, "LF"},
+		{"crlf", "a\r\nb\r\n", []string{"a", "b"}, "CRLF"},
+		{"cr", "a\rb\r", []string{"a", "b"}, "CR"},
+		{"no trailing newline", "a\nb", []string{"a", "b"}, "LF noeol"},
+		{"bom", "\xEF\xBB\xBFa\r\nb\r\n", []string{"a", "b"}, "CRLF BOM"},
+		{"mixed", "a\r\nb\nc\r", []string{"a\r", "b", "c\r"}, "Mixed noeol"},
+		{"empty", "", []string{""}, "LF noeol"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "file.txt")
+			if err := os.WriteFile(filePath, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			fm, err := NewFile(filePath)
+			if err != nil {
+				t.Fatalf("NewFile failed: %v", err)
+			}
+			if got := getStringLines(fm.Buffer); !reflect.DeepEqual(got, tc.lines) {
+				t.Errorf("lines: got %q, want %q", got, tc.lines)
+			}
+			if got := fm.FormatInfo(); got != tc.info {
+				t.Errorf("format: got %q, want %q", got, tc.info)
+			}
+
+			if err := fm.Save(); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			saved, _ := os.ReadFile(filePath)
+			if !bytes.Equal(saved, []byte(tc.content)) {
+				t.Errorf("round trip changed file: got %q, want %q", saved, tc.content)
+			}
+		})
+	}
+}
+
+func TestLineEnding_Convert(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(filePath, []byte("a\r\nb\nc\rd\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fm, err := NewFile(filePath)
+	if err != nil {
+		t.Fatalf("NewFile failed: %v", err)
+	}
+
+	cursor := editor.NewCursor(1, 1)
+	if err := fm.ConvertLineEnding(CRLF, cursor); err != nil {
+		t.Fatalf("ConvertLineEnding failed: %v", err)
+	}
+	if cursor.X != 1 || cursor.Y != 1 {
+		t.Errorf("conversion moved the cursor to %d,%d", cursor.X, cursor.Y)
+	}
+	if got := getStringLines(fm.Buffer); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("conversion lines: got %q", got)
+	}
+	if err := fm.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, _ := os.ReadFile(filePath)
+	if string(saved) != "a\r\nb\r\nc\r\nd\r\n" {
+		t.Errorf("converted file: got %q", saved)
+	}
+
+	// The cleanup of the mixed file is one undo step.
+	fm.History.Undo(fm.Buffer)
+	if got := getStringLines(fm.Buffer); !reflect.DeepEqual(got, []string{"a\r", "b", "c\rd"}) {
+		t.Errorf("undo of conversion: got %q", got)
+	}
+	if fm.LineEnding != Mixed {
+		t.Errorf("undo of conversion: line ending %v, want Mixed", fm.LineEnding)
+	}
+
+	if err := fm.ConvertLineEnding(Mixed, cursor); err == nil {
+		t.Errorf("expected error converting to mixed")
+	}
+}
+
+func TestParseLineEnding(t *testing.T) {
+	for name, want := range map[string]LineEnding{"unix": LF, "CRLF": CRLF, "mac": CR} {
+		if got, err := ParseLineEnding(name); err != nil || got != want {
+			t.Errorf("ParseLineEnding(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseLineEnding("ebcdic"); err == nil {
+		t.Errorf("expected error for unknown line ending")
+	}
+}
+
+func TestLineEnding_ConvertUndo(t *testing.T) {
+	fm, _ := NewEmptyFile("")
+	cursor := editor.NewCursor(0, 0)
+	if err := fm.ConvertLineEnding(CRLF, cursor); err != nil {
+		t.Fatalf("ConvertLineEnding failed: %v", err)
+	}
+	if err := fm.ConvertLineEnding(CR, cursor); err != nil {
+		t.Fatalf("ConvertLineEnding failed: %v", err)
+	}
+
+	for _, want := range []LineEnding{CRLF, LF} {
+		if _, ok := fm.History.Undo(fm.Buffer); !ok || fm.LineEnding != want {
+			t.Errorf("undo: line ending %v, want %v", fm.LineEnding, want)
+		}
+	}
+	for _, want := range []LineEnding{CRLF, CR} {
+		if _, ok := fm.History.Redo(fm.Buffer); !ok || fm.LineEnding != want {
+			t.Errorf("redo: line ending %v, want %v", fm.LineEnding, want)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package data
+
+import "os"
+
+// preserveOwner is a no-op where files have no Unix owner.
+func preserveOwner(f *os.File, info os.FileInfo) {}
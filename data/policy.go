@@ -0,0 +1,117 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Trigger is an event the autosave goroutine hands to its Policy.
+type Trigger int
+
+const (
+	TriggerStart     Trigger = iota // autosave started
+	TriggerTimer                    // the timer the policy last asked for fired
+	TriggerEdit                     // the buffer was edited
+	TriggerFocusLost                // the terminal lost focus
+)
+
+// Policy decides when autosave writes. Handle is called on the autosave
+// goroutine for every trigger; it reports whether to save now and, when
+// next is positive, (re)arms the timer to fire TriggerTimer after next.
+type Policy interface {
+	Handle(t Trigger) (save bool, next time.Duration)
+}
+
+// IntervalPolicy saves every Every, whether or not the user is typing.
+type IntervalPolicy struct {
+	Every time.Duration
+}
+
+func (p IntervalPolicy) Handle(t Trigger) (bool, time.Duration) {
+	switch t {
+	case TriggerStart:
+		return false, p.Every
+	case TriggerTimer:
+		return true, p.Every
+	}
+	return false, 0
+}
+
+// IdlePolicy saves once no edit has been made for After.
+type IdlePolicy struct {
+	After time.Duration
+}
+
+func (p IdlePolicy) Handle(t Trigger) (bool, time.Duration) {
+	switch t {
+	case TriggerEdit:
+		return false, p.After
+	case TriggerTimer:
+		return true, 0
+	}
+	return false, 0
+}
+
+// FocusPolicy saves when the terminal loses focus.
+type FocusPolicy struct{}
+
+func (FocusPolicy) Handle(t Trigger) (bool, time.Duration) {
+	return t == TriggerFocusLost, 0
+}
+
+// EditsPolicy saves after every N edits.
+type EditsPolicy struct {
+	N     int
+	count int
+}
+
+func (p *EditsPolicy) Handle(t Trigger) (bool, time.Duration) {
+	if t != TriggerEdit {
+		return false, 0
+	}
+	p.count++
+	if p.count < p.N {
+		return false, 0
+	}
+	p.count = 0
+	return true, 0
+}
+
+// ParsePolicy reads a policy spec: "interval:5s", "idle:2s", "focus" or
+// "edits:50".
+func ParsePolicy(spec string) (Policy, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	switch strings.ToLower(name) {
+	case "interval", "idle":
+		d, err := time.ParseDuration(arg)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("autosave %s needs a positive duration, got %q", name, arg)
+		}
+		if strings.ToLower(name) == "idle" {
+			return IdlePolicy{After: d}, nil
+		}
+		return IntervalPolicy{Every: d}, nil
+	case "focus":
+		return FocusPolicy{}, nil
+	case "edits":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("autosave edits needs a positive count, got %q", arg)
+		}
+		return &EditsPolicy{N: n}, nil
+	}
+	return nil, fmt.Errorf("unknown autosave policy %q", spec)
+}
+
+// Clock is the time source for autosave, replaced in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Policy
+	}{
+		{"interval:5s", IntervalPolicy{Every: 5 * time.Second}},
+		{"idle:1500ms", IdlePolicy{After: 1500 * time.Millisecond}},
+		{" Focus ", FocusPolicy{}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %v, %v; want %v", tt.spec, got, err, tt.want)
+		}
+	}
+	if p, err := ParsePolicy("edits:50"); err != nil || p.(*EditsPolicy).N != 50 {
+		t.Errorf("ParsePolicy(edits:50) = %v, %v", p, err)
+	}
+	for _, spec := range []string{"", "interval", "idle:-1s", "edits:0", "edits:x", "sometimes"} {
+		if _, err := ParsePolicy(spec); err == nil {
+			t.Errorf("ParsePolicy(%q) should fail", spec)
+		}
+	}
+}
+
+func TestIntervalPolicy(t *testing.T) {
+	p := IntervalPolicy{Every: time.Second}
+	if save, next := p.Handle(TriggerStart); save || next != time.Second {
+		t.Errorf("start: got %v, %v", save, next)
+	}
+	if save, next := p.Handle(TriggerEdit); save || next != 0 {
+		t.Errorf("edits must not move the timer: got %v, %v", save, next)
+	}
+	if save, next := p.Handle(TriggerTimer); !save || next != time.Second {
+		t.Errorf("timer: got %v, %v", save, next)
+	}
+}
+
+func TestIdlePolicy(t *testing.T) {
+	p := IdlePolicy{After: 2 * time.Second}
+	if _, next := p.Handle(TriggerStart); next != 0 {
+		t.Errorf("no timer expected before the first edit, got %v", next)
+	}
+	if save, next := p.Handle(TriggerEdit); save || next != 2*time.Second {
+		t.Errorf("edit: got %v, %v", save, next)
+	}
+	if save, next := p.Handle(TriggerTimer); !save || next != 0 {
+		t.Errorf("timer: got %v, %v", save, next)
+	}
+}
+
+func TestEditsPolicy(t *testing.T) {
+	p := &EditsPolicy{N: 3}
+	var saves []int
+	for i := 1; i <= 7; i++ {
+		if save, _ := p.Handle(TriggerEdit); save {
+			saves = append(saves, i)
+		}
+		p.Handle(TriggerFocusLost) // other triggers don't count
+	}
+	if len(saves) != 2 || saves[0] != 3 || saves[1] != 6 {
+		t.Errorf("expected saves after edits 3 and 6, got %v", saves)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package data
+
+// processAlive can't check other processes here, so every swap file is
+// treated as left over.
+func processAlive(pid int) bool {
+	return false
+}
@@ -0,0 +1,195 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SwapFile mirrors the unsaved content of one open buffer under the state
+// directory so it can be recovered if the editor dies. Named buffers get a
+// swap file keyed by their absolute path; unnamed ones get a unique name.
+// Autosave writes it while the editor may remove it, so mu guards path.
+type SwapFile struct {
+	id   string // distinguishes unnamed buffers
+	mu   sync.Mutex
+	path string // last swap file written, "" if none
+}
+
+// swapHeader is the first line of a swap file; the buffer content follows.
+type swapHeader struct {
+	FilePath string    `json:"filePath"`
+	PID      int       `json:"pid"`
+	Time     time.Time `json:"time"`
+}
+
+// Recovery is a leftover swap file found at startup.
+type Recovery struct {
+	SwapPath string
+	FilePath string
+	Time     time.Time
+	Lines    [][]rune
+}
+
+var swapCounter int
+
+func NewSwapFile() *SwapFile {
+	swapCounter++
+	return &SwapFile{id: fmt.Sprintf("%d-%d-%d", os.Getpid(), time.Now().UnixNano(), swapCounter)}
+}
+
+func swapDir() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "swap"), nil
+}
+
+// swapPath returns where the swap for filePath lives.
+func (s *SwapFile) swapPath(filePath string) (string, error) {
+	dir, err := swapDir()
+	if err != nil {
+		return "", err
+	}
+	if filePath == "" {
+		return filepath.Join(dir, "noname-"+s.id+".swp"), nil
+	}
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, hashContent([]byte(abs))[:32]+".swp"), nil
+}
+
+// Write stores lines as the recoverable content of filePath. When the
+// buffer got a new name since the last write, the old swap is removed.
+func (s *SwapFile) Write(filePath string, lines [][]rune) error {
+	path, err := s.swapPath(filePath)
+	if err != nil {
+		return err
+	}
+	abs := filePath
+	if filePath != "" {
+		abs, _ = filepath.Abs(filePath)
+	}
+	header, err := json.Marshal(swapHeader{FilePath: abs, PID: os.Getpid(), Time: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	var content bytes.Buffer
+	content.Write(header)
+	content.WriteByte('\n')
+	for i, line := range lines {
+		if i > 0 {
+			content.WriteByte('\n')
+		}
+		content.WriteString(string(line))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := ensurePath(path); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, content.Bytes(), false); err != nil {
+		return err
+	}
+	if s.path != "" && s.path != path {
+		os.Remove(s.path)
+	}
+	s.path = path
+	return os.Chmod(path, 0600)
+}
+
+// Remove deletes the swap file, once its content is saved or discarded.
+func (s *SwapFile) Remove() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path != "" {
+		os.Remove(s.path)
+		s.path = ""
+	}
+}
+
+func readSwap(path string) (*Recovery, int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	headerLine, body, _ := bytes.Cut(content, []byte("\n"))
+	var header swapHeader
+	if err := json.Unmarshal(headerLine, &header); err != nil {
+		return nil, 0, fmt.Errorf("bad swap file %s: %w", path, err)
+	}
+	rec := &Recovery{SwapPath: path, FilePath: header.FilePath, Time: header.Time}
+	for _, line := range strings.Split(string(body), "\n") {
+		rec.Lines = append(rec.Lines, []rune(line))
+	}
+	return rec, header.PID, nil
+}
+
+// FindSwap returns the swap file left behind by an editor that is no
+// longer running: the one for filePath, or for "" the newest unnamed one.
+// It returns nil when there is nothing to recover.
+func FindSwap(filePath string) (*Recovery, error) {
+	dir, err := swapDir()
+	if err != nil {
+		return nil, err
+	}
+	if filePath != "" {
+		path, err := (&SwapFile{}).swapPath(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return findOrphan([]string{path})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	paths := []string{}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "noname-") && strings.HasSuffix(e.Name(), ".swp") {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	return findOrphan(paths)
+}
+
+// findOrphan returns the newest readable swap among paths whose editor
+// process has exited.
+func findOrphan(paths []string) (*Recovery, error) {
+	var found []*Recovery
+	for _, path := range paths {
+		rec, pid, err := readSwap(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if processAlive(pid) {
+			continue // still open, here or in another editor
+		}
+		found = append(found, rec)
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Time.After(found[j].Time) })
+	return found[0], nil
+}
+
+// Discard deletes the recovered swap file.
+func (r *Recovery) Discard() error {
+	return os.Remove(r.SwapPath)
+}
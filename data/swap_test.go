@@ -0,0 +1,139 @@
+package data
+
+import (
+	"editGo/editor"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeOrphanSwap leaves a swap file behind as if written by an editor
+// process that has since died.
+func writeOrphanSwap(t *testing.T, filePath string, lines []string, when time.Time) string {
+	t.Helper()
+	sw := NewSwapFile()
+	path, err := sw.swapPath(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _ := json.Marshal(swapHeader{FilePath: filePath, PID: -1, Time: when})
+	content := string(header) + "\n"
+	for i, line := range lines {
+		if i > 0 {
+			content += "\n"
+		}
+		content += line
+	}
+	if err := ensurePath(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSwap_FindsOrphanForFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "draft.txt")
+	writeOrphanSwap(t, filePath, []string{"unsaved", "work"}, time.Now())
+
+	rec, err := FindSwap(filePath)
+	if err != nil || rec == nil {
+		t.Fatalf("expected recovery, got %v, %v", rec, err)
+	}
+	if got := getStringLines(editor.NewTextBufferWithLines(rec.Lines)); !reflect.DeepEqual(got, []string{"unsaved", "work"}) {
+		t.Errorf("recovered lines: got %q", got)
+	}
+
+	if err := rec.Discard(); err != nil {
+		t.Fatalf("Discard failed: %v", err)
+	}
+	if rec, _ := FindSwap(filePath); rec != nil {
+		t.Errorf("expected swap to be gone after discard")
+	}
+}
+
+func TestSwap_NewestUnnamed(t *testing.T) {
+	now := time.Now()
+	old := writeOrphanSwap(t, "", []string{"older"}, now.Add(-time.Hour))
+	newer := writeOrphanSwap(t, "", []string{"newer"}, now)
+	defer os.Remove(old)
+	defer os.Remove(newer)
+
+	rec, err := FindSwap("")
+	if err != nil || rec == nil {
+		t.Fatalf("expected recovery, got %v, %v", rec, err)
+	}
+	if rec.SwapPath != newer {
+		t.Errorf("expected newest unnamed swap, got %s", rec.SwapPath)
+	}
+}
+
+func TestSwap_IgnoresLiveEditor(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "open.txt")
+	sw := NewSwapFile()
+	if err := sw.Write(filePath, [][]rune{[]rune("mine")}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	defer sw.Remove()
+
+	if rec, _ := FindSwap(filePath); rec != nil {
+		t.Errorf("swap of a running editor must not be offered for recovery")
+	}
+}
+
+func TestAutoSave_WritesSwapForUnnamedBuffer(t *testing.T) {
+	fm, _ := NewEmptyFile("")
+	fm.Buffer.SetLines([][]rune{[]rune("scratch")})
+	fm.Buffer.SetDirty(true)
+
+	auto := NewAutoSave(fm, time.Hour)
+	auto.tick()
+
+	rec, _, err := readSwap(fm.Swap.path)
+	if err != nil {
+		t.Fatalf("swap not written: %v", err)
+	}
+	if len(rec.Lines) != 1 || string(rec.Lines[0]) != "scratch" {
+		t.Errorf("swap content: got %q", rec.Lines)
+	}
+
+	if err := fm.SaveAs(filepath.Join(t.TempDir(), "named.txt")); err != nil {
+		t.Fatalf("SaveAs failed: %v", err)
+	}
+	if _, err := os.Stat(rec.SwapPath); !os.IsNotExist(err) {
+		t.Errorf("expected swap removed once the buffer is saved")
+	}
+}
+
+func TestAutoSave_KeepsSwapForNamedBuffer(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "named.txt")
+	fm, _ := NewEmptyFile(filePath)
+	fm.Buffer.InsertRune(0, 0, 'x')
+
+	auto := NewAutoSave(fm, time.Hour)
+	auto.tick()
+	auto.Stop()
+	if fm.Buffer.IsDirty() {
+		t.Fatalf("expected the autosave to succeed")
+	}
+	rec, err := FindSwap(filePath)
+	if err != nil || rec != nil {
+		t.Fatalf("expected our own live swap to be skipped, got %v, %v", rec, err)
+	}
+	if _, _, err := readSwap(fm.Swap.path); err != nil {
+		t.Fatalf("expected a swap kept after autosave and stop: %v", err)
+	}
+
+	swapPath := fm.Swap.path
+	fm.Buffer.InsertRune(0, 1, 'y')
+	if err := fm.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(swapPath); !os.IsNotExist(err) {
+		t.Errorf("expected swap removed by a real save")
+	}
+}
@@ -1,22 +1,29 @@
 package editor
 
+import "sync"
+
+// TextBuffer is the Buffer the editor edits, kept in a PieceTable.
 type TextBuffer struct {
-	Lines [][]rune
-	Dirty bool
+	text *PieceTable
+
+	// mu lets other goroutines, such as autosave, read the buffer while
+	// the editor goroutine, the only writer, changes it.
+	mu sync.RWMutex
 }
 
 func NewTextBuffer() *TextBuffer {
-	return &TextBuffer{
-		Lines: [][]rune{{}},
-		Dirty: false,
-	}
+	return &TextBuffer{text: NewPieceTable(nil)}
 }
 
 func NewTextBufferWithLines(lines [][]rune) *TextBuffer {
-	return &TextBuffer{
-		Lines: lines,
-		Dirty: false,
-	}
+	return &TextBuffer{text: NewPieceTableWithLines(lines)}
+}
+
+// Lines reads a document one line at a time, for callers such as the
+// renderer that only look at a few lines and shouldn't copy the rest.
+type Lines interface {
+	GetLine(line int) []rune
+	LineCount() int
 }
 
 type Buffer interface {
@@ -32,76 +39,73 @@ type Buffer interface {
 }
 
 func (buffer *TextBuffer) InsertRune(line, col int, ch rune) {
-	if buffer.validateIndex(line, col) {
-		lineRunes := buffer.Lines[line]
-		lineRunes = append(lineRunes[:col], append([]rune{ch}, lineRunes[col:]...)...)
-		buffer.Lines[line] = lineRunes
-		buffer.SetDirty(true)
-	}
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	buffer.text.InsertRune(line, col, ch)
 }
 func (buffer *TextBuffer) DeleteRune(line, col int, ch rune) {
-	if buffer.validateIndex(line, col) {
-		if col == 0 { // delete new line
-			if line == 0 {
-				return // top of the buffer
-			}
-			buffer.MergeLine(line - 1) // merge into previous line
-			buffer.SetDirty(true)
-			return
-		}
-		// base case
-		lineRunes := buffer.Lines[line]
-		lineRunes = append(lineRunes[:col-1], lineRunes[col:]...)
-		buffer.Lines[line] = lineRunes
-		buffer.SetDirty(true)
-	}
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	buffer.text.DeleteRune(line, col, ch)
 }
 func (buffer *TextBuffer) InsertNewLine(line, col int) {
-	if line < 0 || line >= len(buffer.Lines) {
-		return
-	}
-	current := buffer.Lines[line]
-
-	if col > len(current) {
-		col = len(current)
-	}
-	before := current[:col]
-	after := current[col:]
-	buffer.Lines[line] = before
-
-	buffer.Lines = append(
-		buffer.Lines[:line+1], append([][]rune{after}, buffer.Lines[line+1:]...)...)
-	buffer.SetDirty(true)
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	buffer.text.InsertNewLine(line, col)
 }
 func (buffer *TextBuffer) MergeLine(line int) {
-	if line < 0 || line+1 >= len(buffer.Lines) {
-		return
-	}
-	buffer.Lines[line] = append(buffer.Lines[line], buffer.Lines[line+1]...)
-	buffer.Lines = append(buffer.Lines[:line+1], buffer.Lines[line+2:]...)
-	buffer.SetDirty(true)
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	buffer.text.MergeLine(line)
 }
+
+// GetLine returns a copy of line, or an empty line if there is none.
 func (buffer *TextBuffer) GetLine(line int) []rune {
-	if line >= 0 && line < len(buffer.Lines) {
-		return buffer.Lines[line]
-	}
-	return []rune{} // should panic
+	buffer.mu.RLock()
+	defer buffer.mu.RUnlock()
+	return buffer.text.GetLine(line)
 }
 func (buffer *TextBuffer) LineCount() int {
-	return len(buffer.Lines)
+	buffer.mu.RLock()
+	defer buffer.mu.RUnlock()
+	return buffer.text.LineCount()
+}
+
+// Lines returns a copy of every line.
+func (buffer *TextBuffer) Lines() [][]rune {
+	buffer.mu.RLock()
+	defer buffer.mu.RUnlock()
+	return buffer.text.Lines()
 }
 func (buffer *TextBuffer) IsDirty() bool {
-	return buffer.Dirty
+	buffer.mu.RLock()
+	defer buffer.mu.RUnlock()
+	return buffer.text.IsDirty()
 }
 func (buffer *TextBuffer) SetDirty(dirty bool) {
-	buffer.Dirty = dirty
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	buffer.text.SetDirty(dirty)
 }
-func (buffer *TextBuffer) validateIndex(line, col int) bool {
-	if line < 0 || line >= len(buffer.Lines) {
-		return false
-	}
-	if col < 0 || col > len(buffer.Lines[line]) {
-		return false
+
+// SetLines replaces the whole content, e.g. when reloading or restoring a
+// snapshot.
+func (buffer *TextBuffer) SetLines(lines [][]rune) {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	buffer.text = NewPieceTableWithLines(lines)
+	buffer.text.SetDirty(true)
+}
+
+// Snapshot returns a copy of the lines that is safe to use on another
+// goroutine, and clears the dirty flag when clean is set. The caller marks
+// the buffer dirty again if it fails to persist the copy.
+func (buffer *TextBuffer) Snapshot(clean bool) (lines [][]rune, wasDirty bool) {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	wasDirty = buffer.text.IsDirty()
+	if clean {
+		buffer.text.SetDirty(false)
 	}
-	return true
+	return buffer.text.Lines(), wasDirty
 }
@@ -62,3 +62,16 @@ func TestMergeLine(t *testing.T) {
 		t.Errorf("MergeLine failed: got %q", string(buf.GetLine(0)))
 	}
 }
+
+func TestInsertNewLine_DoesNotAlias(t *testing.T) {
+	buf := NewTextBuffer()
+	for i, r := range "abcd" {
+		buf.InsertRune(0, i, r)
+	}
+	buf.InsertNewLine(0, 2)
+	buf.InsertRune(0, 2, 'X') // must not overwrite the split-off "cd"
+
+	if string(buf.GetLine(0)) != "abX" || string(buf.GetLine(1)) != "cd" {
+		t.Errorf("InsertNewLine aliasing: got %q, %q", string(buf.GetLine(0)), string(buf.GetLine(1)))
+	}
+}
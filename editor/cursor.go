@@ -18,6 +18,13 @@ type Cursor interface {
 	MoveRight(buffer Buffer)
 	MoveUp(buffer Buffer)
 	MoveDown(buffer Buffer)
+	MoveLines(buffer Buffer, n int)
+	MoveLineStart()
+	MoveLineEnd(buffer Buffer)
+	MoveBufferStart()
+	MoveBufferEnd(buffer Buffer)
+	MoveDisplayUp(buffer Buffer, wrap Wrap)
+	MoveDisplayDown(buffer Buffer, wrap Wrap)
 }
 
 func (c *CursorPointer) GetPosition() (x, y int) {
@@ -35,17 +42,20 @@ func (c *CursorPointer) Clamp(buffer Buffer) {
 	} else if c.Y >= buffer.LineCount() {
 		c.Y = buffer.LineCount() - 1
 	}
-	lineLen := len(buffer.GetLine(c.Y))
+	line := buffer.GetLine(c.Y)
 	if c.X < 0 {
 		c.X = 0
-	} else if c.X > lineLen {
-		c.X = lineLen
+	} else if c.X > len(line) {
+		c.X = len(line)
 	}
+	c.X = clusterStart(line, c.X)
 }
 
+// MoveLeft and MoveRight step over a whole character, so the cursor never
+// lands inside a grapheme cluster such as a letter and its accent.
 func (c *CursorPointer) MoveLeft(buffer Buffer) {
 	if c.X > 0 {
-		c.X--
+		c.X = prevCluster(buffer.GetLine(c.Y), c.X)
 	} else if c.Y > 0 {
 		c.Y--
 		c.X = len(buffer.GetLine(c.Y))
@@ -53,9 +63,9 @@ func (c *CursorPointer) MoveLeft(buffer Buffer) {
 }
 
 func (c *CursorPointer) MoveRight(buffer Buffer) {
-	lineLen := len(buffer.GetLine(c.Y))
-	if c.X < lineLen {
-		c.X++
+	line := buffer.GetLine(c.Y)
+	if c.X < len(line) {
+		c.X = nextCluster(line, c.X)
 	} else if c.Y < buffer.LineCount()-1 {
 		c.Y++
 		c.X = 0
@@ -64,18 +74,32 @@ func (c *CursorPointer) MoveRight(buffer Buffer) {
 func (c *CursorPointer) MoveUp(buffer Buffer) {
 	if c.Y > 0 {
 		c.Y--
-		lineLen := len(buffer.GetLine(c.Y))
-		if c.X > lineLen {
-			c.X = lineLen
-		}
+		c.Clamp(buffer)
 	}
 }
 func (c *CursorPointer) MoveDown(buffer Buffer) {
 	if c.Y < buffer.LineCount()-1 {
 		c.Y++
-		lineLen := len(buffer.GetLine(c.Y))
-		if c.X > lineLen {
-			c.X = lineLen
-		}
+		c.Clamp(buffer)
 	}
 }
+
+// MoveLines moves n lines down, or up for negative n, stopping at the
+// first or last line.
+func (c *CursorPointer) MoveLines(buffer Buffer, n int) {
+	c.Y += n
+	c.Clamp(buffer)
+}
+func (c *CursorPointer) MoveLineStart() {
+	c.X = 0
+}
+func (c *CursorPointer) MoveLineEnd(buffer Buffer) {
+	c.X = len(buffer.GetLine(c.Y))
+}
+func (c *CursorPointer) MoveBufferStart() {
+	c.X, c.Y = 0, 0
+}
+func (c *CursorPointer) MoveBufferEnd(buffer Buffer) {
+	c.Y = buffer.LineCount() - 1
+	c.X = len(buffer.GetLine(c.Y))
+}
@@ -89,3 +89,43 @@ func TestCursor_Clamp(t *testing.T) {
 		}
 	})
 }
+
+func TestCursor_MoveLines(t *testing.T) {
+	buf := &mockBuffer{lines: []string{"long line", "a", "b", "long line"}}
+	cursor := NewCursor(6, 0)
+
+	cursor.MoveLines(buf, 2)
+	if x, y := cursor.GetPosition(); x != 1 || y != 2 {
+		t.Errorf("Expected (1,2), got (%d,%d)", x, y)
+	}
+	cursor.MoveLines(buf, 10)
+	if _, y := cursor.GetPosition(); y != 3 {
+		t.Errorf("Expected to stop at last line, got %d", y)
+	}
+	cursor.MoveLines(buf, -10)
+	if _, y := cursor.GetPosition(); y != 0 {
+		t.Errorf("Expected to stop at first line, got %d", y)
+	}
+}
+
+func TestCursor_LineAndBufferBounds(t *testing.T) {
+	buf := &mockBuffer{lines: []string{"hello", "world!"}}
+	cursor := NewCursor(2, 0)
+
+	cursor.MoveLineEnd(buf)
+	if x, y := cursor.GetPosition(); x != 5 || y != 0 {
+		t.Errorf("End: expected (5,0), got (%d,%d)", x, y)
+	}
+	cursor.MoveLineStart()
+	if x, _ := cursor.GetPosition(); x != 0 {
+		t.Errorf("Home: expected x 0, got %d", x)
+	}
+	cursor.MoveBufferEnd(buf)
+	if x, y := cursor.GetPosition(); x != 6 || y != 1 {
+		t.Errorf("Ctrl+End: expected (6,1), got (%d,%d)", x, y)
+	}
+	cursor.MoveBufferStart()
+	if x, y := cursor.GetPosition(); x != 0 || y != 0 {
+		t.Errorf("Ctrl+Home: expected (0,0), got (%d,%d)", x, y)
+	}
+}
@@ -0,0 +1,94 @@
+package editor
+
+type DiffKind int
+
+const (
+	DiffEqual DiffKind = iota
+	DiffDelete
+	DiffInsert
+)
+
+// DiffLine is one line of a line-by-line diff.
+type DiffLine struct {
+	Kind DiffKind
+	Text string
+}
+
+// maxDiffCells bounds the LCS table; larger changes fall back to showing
+// the differing region as a block delete followed by a block insert.
+const maxDiffCells = 4_000_000
+
+// DiffLines returns the edits that turn a into b, using the longest
+// common subsequence of lines after trimming the common prefix and suffix.
+func DiffLines(a, b [][]rune) []DiffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && string(a[prefix]) == string(b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		string(a[len(a)-1-suffix]) == string(b[len(b)-1-suffix]) {
+		suffix++
+	}
+
+	out := []DiffLine{}
+	for _, line := range a[:prefix] {
+		out = append(out, DiffLine{DiffEqual, string(line)})
+	}
+	out = append(out, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		out = append(out, DiffLine{DiffEqual, string(line)})
+	}
+	return out
+}
+
+func diffMiddle(a, b [][]rune) []DiffLine {
+	out := []DiffLine{}
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			out = append(out, DiffLine{DiffDelete, string(line)})
+		}
+		for _, line := range b {
+			out = append(out, DiffLine{DiffInsert, string(line)})
+		}
+		return out
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if string(a[i]) == string(b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case string(a[i]) == string(b[j]):
+			out = append(out, DiffLine{DiffEqual, string(a[i])})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, DiffLine{DiffDelete, string(a[i])})
+			i++
+		default:
+			out = append(out, DiffLine{DiffInsert, string(b[j])})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, DiffLine{DiffDelete, string(a[i])})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, DiffLine{DiffInsert, string(b[j])})
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func toRuneLines(lines ...string) [][]rune {
+	out := [][]rune{}
+	for _, line := range lines {
+		out = append(out, []rune(line))
+	}
+	return out
+}
+
+func TestDiffLines(t *testing.T) {
+	a := toRuneLines("one", "two", "three", "four")
+	b := toRuneLines("one", "2", "three", "four", "five")
+
+	want := []DiffLine{
+		{DiffEqual, "one"},
+		{DiffDelete, "two"},
+		{DiffInsert, "2"},
+		{DiffEqual, "three"},
+		{DiffEqual, "four"},
+		{DiffInsert, "five"},
+	}
+	if got := DiffLines(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLines mismatch:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+func TestDiffLines_Identical(t *testing.T) {
+	a := toRuneLines("same", "lines")
+	for _, d := range DiffLines(a, a) {
+		if d.Kind != DiffEqual {
+			t.Errorf("expected only equal lines, got %v", d)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package editor
+
+import (
+	"github.com/rivo/uniseg"
+	"iter"
+	"unicode/utf8"
+)
+
+const DefaultTabWidth = 4
+
+// Cluster is one user-perceived character of a line, such as "é" typed
+// as e plus a combining accent or an emoji with a skin tone: the runes
+// [Start, End), drawn from screen column Col and Width columns wide.
+type Cluster struct {
+	Start int
+	End   int
+	Col   int
+	Width int
+}
+
+// Layout splits line into grapheme clusters and places them on screen.
+// Tabs reach to the next multiple of tabWidth, CJK characters and most
+// emoji take two columns, and other control characters are shown as two
+// columns, like ^M.
+func Layout(line []rune, tabWidth int) []Cluster {
+	clusters := make([]Cluster, 0, len(line))
+	for c := range layoutFrom(line, 0, 0, tabWidth) {
+		clusters = append(clusters, c)
+	}
+	return clusters
+}
+
+// LayoutColumns is Layout for only the clusters at least partly inside
+// the screen columns [from, to), so a long line is not laid out past what
+// is shown. It also returns the column just past the end of the line, or
+// to if the line reaches further.
+func LayoutColumns(line []rune, from, to, tabWidth int) (clusters []Cluster, end int) {
+	for c := range layoutFrom(line, 0, 0, tabWidth) {
+		if c.Col >= to {
+			return clusters, to
+		}
+		if c.Col+c.Width > from {
+			clusters = append(clusters, c)
+		}
+		end = c.Col + c.Width
+	}
+	return clusters, end
+}
+
+// layoutFrom yields the clusters of line from rune index start, which
+// must be a boundary, placed from screen column col. Grapheme
+// segmentation is only run on the stretches that need it: an ASCII
+// character followed by another is always a cluster of its own.
+func layoutFrom(line []rune, start, col, tabWidth int) iter.Seq[Cluster] {
+	tabWidth = max(tabWidth, 1)
+	return func(yield func(Cluster) bool) {
+		start, col := start, col
+		place := func(end, width int) bool {
+			switch r := line[start]; {
+			case r == '\t':
+				width = tabWidth - col%tabWidth
+			case IsControl(r):
+				width = 2
+			}
+			c := Cluster{Start: start, End: end, Col: col, Width: width}
+			start, col = end, col+width
+			return yield(c)
+		}
+		for start < len(line) {
+			if line[start] < utf8.RuneSelf && boundary(line, start+1) {
+				if !place(start+1, 1) {
+					return
+				}
+				continue
+			}
+			rest := string(line[start:nextBoundary(line, start+1)])
+			state := -1
+			for rest != "" {
+				var cluster string
+				var width int
+				cluster, rest, width, state = uniseg.FirstGraphemeClusterInString(rest, state)
+				if !place(start+utf8.RuneCountInString(cluster), width) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// boundary reports whether a cluster starts at rune index i whatever
+// comes before it: at either end of the line, or between two ASCII
+// characters other than a CR, which may pair with a following LF.
+func boundary(line []rune, i int) bool {
+	return i <= 0 || i >= len(line) ||
+		line[i-1] < utf8.RuneSelf && line[i-1] != '\r' && line[i] < utf8.RuneSelf
+}
+
+// nextBoundary returns the first boundary at or after i.
+func nextBoundary(line []rune, i int) int {
+	for !boundary(line, i) {
+		i++
+	}
+	return i
+}
+
+// lastBoundary returns the last boundary at or before i.
+func lastBoundary(line []rune, i int) int {
+	for !boundary(line, i) {
+		i--
+	}
+	return i
+}
+
+// IsControl reports whether r is drawn in caret notation rather than as
+// itself; tabs are expanded instead.
+func IsControl(r rune) bool {
+	return (r < 0x20 && r != '\t') || r == 0x7f
+}
+
+// DisplayWidth returns how many columns line takes on screen.
+func DisplayWidth(line []rune, tabWidth int) int {
+	width := 0
+	for c := range layoutFrom(line, 0, 0, tabWidth) {
+		width = c.Col + c.Width
+	}
+	return width
+}
+
+// DisplayColumn returns the screen column the character at rune index x
+// starts at; the end of the line is the column just past it.
+func DisplayColumn(line []rune, x, tabWidth int) int {
+	col := 0
+	for c := range layoutFrom(line, 0, 0, tabWidth) {
+		if x < c.End {
+			return c.Col
+		}
+		col = c.Col + c.Width
+	}
+	return col
+}
+
+// RuneAt returns the rune index of the character covering screen column
+// col, or len(line) when col is past the end.
+func RuneAt(line []rune, col, tabWidth int) int {
+	for c := range layoutFrom(line, 0, 0, tabWidth) {
+		if col < c.Col+c.Width {
+			return c.Start
+		}
+	}
+	return len(line)
+}
+
+// clusterAt returns the character that rune index x is in, segmenting
+// only from the last boundary before it. ok is false at the end of the
+// line.
+func clusterAt(line []rune, x int) (c Cluster, ok bool) {
+	for c := range layoutFrom(line, lastBoundary(line, x), 0, 1) {
+		if x < c.End {
+			return c, true
+		}
+	}
+	return Cluster{}, false
+}
+
+// clusterStart moves x back to the start of the character it is in.
+func clusterStart(line []rune, x int) int {
+	if c, ok := clusterAt(line, x); ok {
+		return c.Start
+	}
+	return len(line)
+}
+
+// nextCluster returns the start of the character after the one at x.
+func nextCluster(line []rune, x int) int {
+	if c, ok := clusterAt(line, x); ok {
+		return c.End
+	}
+	return len(line)
+}
+
+// prevCluster returns the start of the character before x.
+func prevCluster(line []rune, x int) int {
+	if x <= 0 {
+		return 0
+	}
+	return clusterStart(line, x-1)
+}
@@ -0,0 +1,210 @@
+package editor
+
+import (
+	"fmt"
+	"github.com/rivo/uniseg"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLayout_MixedWidths(t *testing.T) {
+	tests := []struct {
+		line   string
+		starts []int // rune index of each cluster
+		cols   []int // screen column of each cluster
+		width  int
+	}{
+		{"abc", []int{0, 1, 2}, []int{0, 1, 2}, 3},
+		{"日本語", []int{0, 1, 2}, []int{0, 2, 4}, 6},
+		{"日", []int{0}, []int{0}, 2},
+		{"ab日", []int{0, 1, 2}, []int{0, 1, 2}, 4},
+		{"éx", []int{0, 2}, []int{0, 1}, 2},        // e + combining acute
+		{"👍🏽!", []int{0, 2}, []int{0, 2}, 3},        // emoji + skin tone
+		{"👩‍💻a", []int{0, 3}, []int{0, 2}, 3},       // ZWJ sequence
+		{"a\tb", []int{0, 1, 2}, []int{0, 1, 4}, 5}, // tab to column 4
+		{"\tx", []int{0, 1}, []int{0, 4}, 5},        // full tab
+		{"abcd\tx", []int{0, 1, 2, 3, 4, 5}, []int{0, 1, 2, 3, 4, 8}, 9},
+		{"a\rb", []int{0, 1, 2}, []int{0, 1, 3}, 4}, // ^M
+	}
+	for _, tt := range tests {
+		line := []rune(tt.line)
+		clusters := Layout(line, 4)
+		var starts, cols []int
+		for _, c := range clusters {
+			starts = append(starts, c.Start)
+			cols = append(cols, c.Col)
+		}
+		if fmt.Sprint(starts) != fmt.Sprint(tt.starts) || fmt.Sprint(cols) != fmt.Sprint(tt.cols) {
+			t.Errorf("Layout(%q): starts %v cols %v, want %v %v", tt.line, starts, cols, tt.starts, tt.cols)
+		}
+		if got := DisplayWidth(line, 4); got != tt.width {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.line, got, tt.width)
+		}
+	}
+}
+
+// segmentWhole lays line out by running grapheme segmentation over all of
+// it, as Layout did before skipping ASCII.
+func segmentWhole(line []rune, tabWidth int) []Cluster {
+	var clusters []Cluster
+	rest := string(line)
+	start, col, state := 0, 0, -1
+	for rest != "" {
+		var cluster string
+		var width int
+		cluster, rest, width, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		end := start + utf8.RuneCountInString(cluster)
+		switch r := line[start]; {
+		case r == '\t':
+			width = tabWidth - col%tabWidth
+		case IsControl(r):
+			width = 2
+		}
+		clusters = append(clusters, Cluster{Start: start, End: end, Col: col, Width: width})
+		start, col = end, col+width
+	}
+	return clusters
+}
+
+// TestLayout_MatchesWholeLineSegmentation checks that segmenting only the
+// non-ASCII stretches, or only from the boundary before the cursor, finds
+// the same characters as segmenting the whole line.
+func TestLayout_MatchesWholeLineSegmentation(t *testing.T) {
+	pieces := []string{"a", "b", " ", "\t", "\r", "\n", "\r\n", "日", "\u0301", "👍", "🏽", "\u200d", "💻", "🇫", "🇷", "#\ufe0f\u20e3", "\u1100", "\u1161", "e\u0301"}
+	rng := rand.New(rand.NewSource(1))
+	for range 2000 {
+		var b strings.Builder
+		for range rng.Intn(12) {
+			b.WriteString(pieces[rng.Intn(len(pieces))])
+		}
+		line := []rune(b.String())
+		want := segmentWhole(line, 4)
+		if got := Layout(line, 4); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("Layout(%q) = %v, want %v", b.String(), got, want)
+		}
+		for x := 0; x <= len(line); x++ {
+			start, next, prev := len(line), len(line), 0
+			for _, c := range want {
+				if c.Start < x {
+					prev = c.Start
+				}
+				if x < c.End && start == len(line) {
+					start, next = c.Start, c.End
+				}
+			}
+			if got := clusterStart(line, x); got != start {
+				t.Fatalf("clusterStart(%q, %d) = %d, want %d", b.String(), x, got, start)
+			}
+			if got := nextCluster(line, x); got != next {
+				t.Fatalf("nextCluster(%q, %d) = %d, want %d", b.String(), x, got, next)
+			}
+			if got := prevCluster(line, x); got != prev {
+				t.Fatalf("prevCluster(%q, %d) = %d, want %d", b.String(), x, got, prev)
+			}
+		}
+	}
+}
+
+func TestLayoutColumns(t *testing.T) {
+	line := []rune("ab日cd")
+	tests := []struct {
+		from, to int
+		starts   string
+		end      int
+	}{
+		{0, 10, "[0 1 2 3 4]", 6},
+		{1, 3, "[1 2]", 3}, // 日 is cut by the right edge
+		{3, 5, "[2 3]", 5},
+		{6, 9, "[]", 6},
+		{8, 9, "[]", 6},
+	}
+	for _, tt := range tests {
+		clusters, end := LayoutColumns(line, tt.from, tt.to, 4)
+		starts := []int{}
+		for _, c := range clusters {
+			starts = append(starts, c.Start)
+		}
+		if fmt.Sprint(starts) != tt.starts || end != tt.end {
+			t.Errorf("LayoutColumns(%d, %d) = %v %d, want %s %d", tt.from, tt.to, starts, end, tt.starts, tt.end)
+		}
+	}
+}
+
+func TestDisplayColumnAndRuneAt(t *testing.T) {
+	line := []rune("a日\tb")
+	// a:0, 日:1-2, tab:3, b:4
+	for x, want := range map[int]int{0: 0, 1: 1, 2: 3, 3: 4, 4: 5} {
+		if got := DisplayColumn(line, x, 4); got != want {
+			t.Errorf("DisplayColumn(%d) = %d, want %d", x, got, want)
+		}
+	}
+	for col, want := range map[int]int{0: 0, 1: 1, 2: 1, 3: 2, 4: 3, 9: 4} {
+		if got := RuneAt(line, col, 4); got != want {
+			t.Errorf("RuneAt(%d) = %d, want %d", col, got, want)
+		}
+	}
+}
+
+func TestCursor_MovesByGraphemeCluster(t *testing.T) {
+	buf := &mockBuffer{lines: []string{"é👍🏽x"}}
+	cursor := NewCursor(0, 0)
+
+	for _, want := range []int{2, 4, 5} {
+		cursor.MoveRight(buf)
+		if cursor.X != want {
+			t.Fatalf("MoveRight: expected x %d, got %d", want, cursor.X)
+		}
+	}
+	for _, want := range []int{4, 2, 0} {
+		cursor.MoveLeft(buf)
+		if cursor.X != want {
+			t.Fatalf("MoveLeft: expected x %d, got %d", want, cursor.X)
+		}
+	}
+}
+
+func TestCursor_ClampSnapsToClusterStart(t *testing.T) {
+	buf := &mockBuffer{lines: []string{"xé", "abc"}}
+	cursor := NewCursor(2, 1)
+
+	cursor.MoveUp(buf)
+	if x, y := cursor.GetPosition(); x != 1 || y != 0 {
+		t.Errorf("expected (1,0) at the start of é, got (%d,%d)", x, y)
+	}
+}
+
+func TestWrap_WideCharacters(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"日本語です", []string{"日本", "語で", "す"}},
+		{"a日本", []string{"a日", "本"}}, // 本 doesn't fit in the one column left
+		{"\tab", []string{"\t", "ab"}},
+	}
+	for _, tt := range tests {
+		wrap := Wrap{Width: 4, TabWidth: 4}
+		got := segmentText(tt.line, wrap.Segments([]rune(tt.line)))
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Segments(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCursor_MoveDisplayKeepsScreenColumn(t *testing.T) {
+	buf := &mockBuffer{lines: []string{"日本語", "abcdef"}}
+	wrap := Wrap{Width: 10, TabWidth: 4}
+	cursor := NewCursor(4, 1) // column 4
+
+	cursor.MoveDisplayUp(buf, wrap)
+	if cursor.X != 2 {
+		t.Errorf("expected 語 at column 4, got x %d", cursor.X)
+	}
+	cursor.X = 1 // 本, column 2
+	cursor.MoveDisplayDown(buf, wrap)
+	if cursor.X != 2 {
+		t.Errorf("expected column 2 on the next line, got x %d", cursor.X)
+	}
+}
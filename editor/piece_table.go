@@ -0,0 +1,402 @@
+package editor
+
+import (
+	"slices"
+	"sort"
+)
+
+type pieceSource int
+
+const (
+	sourceOriginal pieceSource = iota
+	sourceAdd
+)
+
+// piece is a span of runes taken from either the original or the add buffer.
+type piece struct {
+	source   pieceSource
+	start    int
+	length   int
+	newlines int
+}
+
+var _ Buffer = (*PieceTable)(nil)
+
+// PieceTable is a Buffer that never rewrites the text it was loaded with.
+// The original content stays read-only, typed text is appended to an
+// add buffer, and the document is described by the ordered list of pieces.
+// It backs TextBuffer, which adds the locking.
+type PieceTable struct {
+	original       []rune
+	add            []rune
+	originalBreaks []int // offsets of '\n' in original
+	addBreaks      []int // offsets of '\n' in add
+	pieces         []piece
+	length         int
+	Dirty          bool
+
+	lineStarts  offsets // where each line starts
+	pieceStarts offsets // where each piece starts
+}
+
+// offsets is an ascending list of document offsets. An edit moves every
+// offset after it; rather than touching all of them on each keystroke,
+// the move is kept as a pending shift of the entries after one index and
+// only applied when an edit elsewhere needs them exact.
+type offsets struct {
+	at    []int
+	after int // at[after+1:] are short by shift
+	shift int
+}
+
+func (o *offsets) get(i int) int {
+	if i > o.after {
+		return o.at[i] + o.shift
+	}
+	return o.at[i]
+}
+
+func (o *offsets) set(i, offset int) {
+	if i > o.after {
+		offset -= o.shift
+	}
+	o.at[i] = offset
+}
+
+// search returns the index of the last offset at or before pos.
+func (o *offsets) search(pos int) int {
+	return sort.Search(len(o.at), func(i int) bool { return o.get(i) > pos }) - 1
+}
+
+// add moves every offset after index i by delta.
+func (o *offsets) add(i, delta int) {
+	if o.after != i {
+		o.flush()
+		o.after = i
+	}
+	o.shift += delta
+}
+
+// flush applies the pending shift.
+func (o *offsets) flush() {
+	if o.shift == 0 {
+		return
+	}
+	for i := o.after + 1; i < len(o.at); i++ {
+		o.at[i] += o.shift
+	}
+	o.shift = 0
+}
+
+// splice replaces the n offsets from index i with the given ones.
+func (o *offsets) splice(i, n int, with ...int) {
+	if n == len(with) {
+		for j, offset := range with {
+			o.set(i+j, offset)
+		}
+		return
+	}
+	o.flush()
+	o.at = slices.Replace(o.at, i, i+n, with...)
+}
+
+func NewPieceTable(original []byte) *PieceTable {
+	return newPieceTable([]rune(string(original)))
+}
+
+// NewPieceTableWithLines loads lines, joined by '\n'.
+func NewPieceTableWithLines(lines [][]rune) *PieceTable {
+	n := max(len(lines)-1, 0)
+	for _, line := range lines {
+		n += len(line)
+	}
+	runes := make([]rune, 0, n)
+	for i, line := range lines {
+		if i > 0 {
+			runes = append(runes, '\n')
+		}
+		runes = append(runes, line...)
+	}
+	return newPieceTable(runes)
+}
+
+func newPieceTable(runes []rune) *PieceTable {
+	pt := &PieceTable{
+		original:       runes,
+		originalBreaks: lineBreaks(runes, 0),
+		length:         len(runes),
+	}
+	pt.lineStarts.at = make([]int, 1, len(pt.originalBreaks)+1)
+	for _, brk := range pt.originalBreaks {
+		pt.lineStarts.at = append(pt.lineStarts.at, brk+1)
+	}
+	if len(runes) > 0 {
+		pt.pieces = []piece{{
+			source:   sourceOriginal,
+			start:    0,
+			length:   len(runes),
+			newlines: len(pt.originalBreaks),
+		}}
+		pt.pieceStarts.at = []int{0}
+	}
+	return pt
+}
+
+func (pt *PieceTable) InsertRune(line, col int, ch rune) {
+	if pt.validateIndex(line, col) {
+		pt.insert(pt.offset(line, col), []rune{ch})
+		pt.SetDirty(true)
+	}
+}
+func (pt *PieceTable) DeleteRune(line, col int, ch rune) {
+	if pt.validateIndex(line, col) {
+		if col == 0 { // delete new line
+			if line == 0 {
+				return // top of the buffer
+			}
+			pt.MergeLine(line - 1) // merge into previous line
+			pt.SetDirty(true)
+			return
+		}
+		pt.delete(pt.offset(line, col-1), 1)
+		pt.SetDirty(true)
+	}
+}
+func (pt *PieceTable) InsertNewLine(line, col int) {
+	if line < 0 || line >= pt.LineCount() {
+		return
+	}
+	lineLen := pt.lineLength(line)
+	if col > lineLen {
+		col = lineLen
+	} else if col < 0 {
+		col = 0
+	}
+	pt.insert(pt.offset(line, col), []rune{'\n'})
+	pt.SetDirty(true)
+}
+func (pt *PieceTable) MergeLine(line int) {
+	if line < 0 || line+1 >= pt.LineCount() {
+		return
+	}
+	pt.delete(pt.lineStart(line+1)-1, 1)
+	pt.SetDirty(true)
+}
+func (pt *PieceTable) GetLine(line int) []rune {
+	if line < 0 || line >= pt.LineCount() {
+		return []rune{}
+	}
+	start := pt.lineStart(line)
+	return pt.slice(start, start+pt.lineLength(line))
+}
+func (pt *PieceTable) LineCount() int {
+	return len(pt.lineStarts.at)
+}
+
+// Lines returns a copy of every line.
+func (pt *PieceTable) Lines() [][]rune {
+	text := pt.slice(0, pt.length)
+	lines := make([][]rune, 0, pt.LineCount())
+	start := 0
+	for i, r := range text {
+		if r == '\n' {
+			lines = append(lines, text[start:i:i]) // capped, so appending copies
+			start = i + 1
+		}
+	}
+	return append(lines, text[start:len(text):len(text)])
+}
+
+func (pt *PieceTable) IsDirty() bool {
+	return pt.Dirty
+}
+func (pt *PieceTable) SetDirty(dirty bool) {
+	pt.Dirty = dirty
+}
+
+// String returns the whole document with lines joined by '\n'.
+func (pt *PieceTable) String() string {
+	return string(pt.slice(0, pt.length))
+}
+
+func (pt *PieceTable) validateIndex(line, col int) bool {
+	if line < 0 || line >= pt.LineCount() {
+		return false
+	}
+	if col < 0 || col > pt.lineLength(line) {
+		return false
+	}
+	return true
+}
+
+func (pt *PieceTable) runes(src pieceSource) []rune {
+	if src == sourceAdd {
+		return pt.add
+	}
+	return pt.original
+}
+
+func (pt *PieceTable) breaks(src pieceSource) []int {
+	if src == sourceAdd {
+		return pt.addBreaks
+	}
+	return pt.originalBreaks
+}
+
+// countBreaks returns how many '\n' runes fall in src[start:end].
+func (pt *PieceTable) countBreaks(src pieceSource, start, end int) int {
+	b := pt.breaks(src)
+	return sort.SearchInts(b, end) - sort.SearchInts(b, start)
+}
+
+// offset converts a line/column position into a document offset.
+func (pt *PieceTable) offset(line, col int) int {
+	return pt.lineStart(line) + col
+}
+
+// lineStart returns the document offset of the first rune of line.
+func (pt *PieceTable) lineStart(line int) int {
+	if line <= 0 {
+		return 0
+	}
+	if line >= pt.LineCount() {
+		return pt.length
+	}
+	return pt.lineStarts.get(line)
+}
+
+func (pt *PieceTable) lineLength(line int) int {
+	start := pt.lineStart(line)
+	if line+1 >= pt.LineCount() {
+		return pt.length - start
+	}
+	return pt.lineStart(line+1) - 1 - start
+}
+
+// slice copies the document runes in [start, end).
+func (pt *PieceTable) slice(start, end int) []rune {
+	out := make([]rune, 0, end-start)
+	index, inner := pt.findPiece(start)
+	for pos := start - inner; index < len(pt.pieces) && pos < end; index++ {
+		p := pt.pieces[index]
+		pEnd := pos + p.length
+		from := max(start, pos) - pos
+		to := min(end, pEnd) - pos
+		out = append(out, pt.runes(p.source)[p.start+from:p.start+to]...)
+		pos = pEnd
+	}
+	return out
+}
+
+// findPiece returns the index of the piece containing offset and the
+// offset's position inside it. An offset at the very end of the document
+// yields len(pt.pieces).
+func (pt *PieceTable) findPiece(offset int) (index, inner int) {
+	if offset >= pt.length {
+		return len(pt.pieces), 0
+	}
+	index = pt.pieceStarts.search(offset)
+	return index, offset - pt.pieceStarts.get(index)
+}
+
+func (pt *PieceTable) newPiece(src pieceSource, start, length int) piece {
+	return piece{
+		source:   src,
+		start:    start,
+		length:   length,
+		newlines: pt.countBreaks(src, start, start+length),
+	}
+}
+
+func (pt *PieceTable) insert(offset int, text []rune) {
+	index, inner := pt.findPiece(offset)
+	line := pt.lineStarts.search(offset)
+	start := len(pt.add)
+	pt.addBreaks = append(pt.addBreaks, lineBreaks(text, start)...)
+	pt.add = append(pt.add, text...)
+	added := pt.countBreaks(sourceAdd, start, start+len(text))
+	pt.length += len(text)
+
+	lineStarts := make([]int, 0, added)
+	for _, brk := range lineBreaks(text, offset) {
+		lineStarts = append(lineStarts, brk+1)
+	}
+	pt.lineStarts.splice(line+1, 0, lineStarts...)
+	pt.lineStarts.add(line+added, len(text))
+
+	if inner == 0 && index > 0 {
+		// Typing usually continues right after the previous insertion,
+		// so grow that piece instead of adding a new one.
+		prev := &pt.pieces[index-1]
+		if prev.source == sourceAdd && prev.start+prev.length == start {
+			prev.length += len(text)
+			prev.newlines += added
+			pt.pieceStarts.add(index-1, len(text))
+			return
+		}
+	}
+
+	np := piece{source: sourceAdd, start: start, length: len(text), newlines: added}
+	if inner == 0 {
+		pt.pieces = slices.Insert(pt.pieces, index, np)
+		pt.pieceStarts.splice(index, 0, offset)
+		pt.pieceStarts.add(index, len(text))
+		return
+	}
+	p := pt.pieces[index]
+	left := pt.newPiece(p.source, p.start, inner)
+	right := pt.newPiece(p.source, p.start+inner, p.length-inner)
+	pt.pieces = slices.Replace(pt.pieces, index, index+1, left, np, right)
+	pt.pieceStarts.splice(index+1, 0, offset, offset)
+	pt.pieceStarts.add(index+1, len(text))
+}
+
+func (pt *PieceTable) delete(offset, length int) {
+	if length <= 0 || offset < 0 || offset+length > pt.length {
+		return
+	}
+	end := offset + length
+	first, inner := pt.findPiece(offset)
+	line := pt.lineStarts.search(offset)
+
+	// Only the pieces the deleted span touches change: they are replaced
+	// by what is left of the first and last of them.
+	var kept []piece
+	var keptStarts []int
+	pos := offset - inner
+	if inner > 0 {
+		p := pt.pieces[first]
+		kept = append(kept, pt.newPiece(p.source, p.start, inner))
+		keptStarts = append(keptStarts, pos)
+	}
+	removed := 0
+	i := first
+	for ; i < len(pt.pieces) && pos < end; i++ {
+		p := pt.pieces[i]
+		pEnd := pos + p.length
+		from := max(offset, pos) - pos
+		to := min(end, pEnd) - pos
+		removed += pt.countBreaks(p.source, p.start+from, p.start+to)
+		if pEnd > end {
+			kept = append(kept, pt.newPiece(p.source, p.start+to, pEnd-end))
+			keptStarts = append(keptStarts, offset)
+		}
+		pos = pEnd
+	}
+	pt.pieces = slices.Replace(pt.pieces, first, i, kept...)
+	pt.pieceStarts.splice(first, i-first, keptStarts...)
+	pt.pieceStarts.add(first+len(kept)-1, -length)
+	pt.lineStarts.splice(line+1, removed)
+	pt.lineStarts.add(line, -length)
+	pt.length -= length
+}
+
+func lineBreaks(text []rune, base int) []int {
+	breaks := []int{}
+	for i, r := range text {
+		if r == '\n' {
+			breaks = append(breaks, base+i)
+		}
+	}
+	return breaks
+}
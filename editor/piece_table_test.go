@@ -0,0 +1,298 @@
+package editor
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func pieceLines(pt *PieceTable) []string {
+	lines := []string{}
+	for i := 0; i < pt.LineCount(); i++ {
+		lines = append(lines, string(pt.GetLine(i)))
+	}
+	return lines
+}
+
+func TestPieceTable_Load(t *testing.T) {
+	pt := NewPieceTable([]byte("one\ntwo\n\nfour"))
+
+	want := []string{"one", "two", "", "four"}
+	if got := pieceLines(pt); !reflect.DeepEqual(got, want) {
+		t.Errorf("Load failed: got %q, want %q", got, want)
+	}
+
+	empty := NewPieceTable(nil)
+	if empty.LineCount() != 1 || len(empty.GetLine(0)) != 0 {
+		t.Errorf("expected empty table to have one empty line")
+	}
+}
+
+func TestPieceTable_InsertDelete(t *testing.T) {
+	pt := NewPieceTable([]byte("Hlo"))
+	pt.InsertRune(0, 1, 'e')
+	pt.InsertRune(0, 2, 'l')
+	if got := string(pt.GetLine(0)); got != "Hello" {
+		t.Errorf("InsertRune failed: got %q", got)
+	}
+
+	pt.DeleteRune(0, 5, 'o')
+	if got := string(pt.GetLine(0)); got != "Hell" {
+		t.Errorf("DeleteRune failed: got %q", got)
+	}
+	if !pt.IsDirty() {
+		t.Errorf("expected table to be dirty after edits")
+	}
+}
+
+func TestPieceTable_NewLineAndMerge(t *testing.T) {
+	pt := NewPieceTable([]byte("Hi"))
+	pt.InsertNewLine(0, 1)
+	if got := pieceLines(pt); !reflect.DeepEqual(got, []string{"H", "i"}) {
+		t.Errorf("InsertNewLine failed: got %q", got)
+	}
+
+	pt.DeleteRune(1, 0, 0) // backspace at line start merges
+	if got := pieceLines(pt); !reflect.DeepEqual(got, []string{"Hi"}) {
+		t.Errorf("DeleteRune at col 0 failed: got %q", got)
+	}
+
+	pt.MergeLine(0) // nothing below, no-op
+	if pt.LineCount() != 1 {
+		t.Errorf("MergeLine on last line should be a no-op")
+	}
+}
+
+func TestPieceTable_InvalidIndex(t *testing.T) {
+	pt := NewPieceTable([]byte("abc"))
+	pt.InsertRune(0, 10, 'x')
+	pt.InsertRune(5, 0, 'x')
+	pt.DeleteRune(0, 0, 0)
+	pt.InsertNewLine(3, 0)
+
+	if got := pieceLines(pt); !reflect.DeepEqual(got, []string{"abc"}) {
+		t.Errorf("expected invalid edits to be ignored, got %q", got)
+	}
+	if pt.IsDirty() {
+		t.Errorf("expected table to stay clean")
+	}
+	if got := pt.GetLine(7); len(got) != 0 {
+		t.Errorf("expected empty out-of-range line, got %q", string(got))
+	}
+}
+
+// TestPieceTable_MatchesLines replays random edits on a piece table and on
+// a plain slice of lines, and expects identical content after each step.
+func TestPieceTable_MatchesLines(t *testing.T) {
+	text := "package main\n\nfunc main() {\n\tprintln(\"héllo\")\n}\n"
+	want := strings.Split(text, "\n")
+	pt := NewPieceTable([]byte(text))
+	rng := rand.New(rand.NewSource(1))
+
+	for step := 0; step < 2000; step++ {
+		line := rng.Intn(len(want))
+		runes := []rune(want[line])
+		col := rng.Intn(len(runes) + 1)
+		switch rng.Intn(4) {
+		case 0, 1:
+			r := rune('a' + rng.Intn(26))
+			pt.InsertRune(line, col, r)
+			want[line] = string(runes[:col]) + string(r) + string(runes[col:])
+		case 2:
+			pt.DeleteRune(line, col, 0)
+			if col > 0 {
+				want[line] = string(runes[:col-1]) + string(runes[col:])
+			} else if line > 0 {
+				want = mergeLines(want, line-1)
+			}
+		case 3:
+			if rng.Intn(2) == 0 {
+				pt.InsertNewLine(line, col)
+				want = append(want[:line+1], want[line:]...)
+				want[line], want[line+1] = string(runes[:col]), string(runes[col:])
+			} else {
+				pt.MergeLine(line)
+				want = mergeLines(want, line)
+			}
+		}
+
+		if got := pieceLines(pt); !reflect.DeepEqual(got, want) {
+			t.Fatalf("step %d: piece table diverged\ngot  %q\nwant %q", step, got, want)
+		}
+		if pt.LineCount() != len(want) {
+			t.Fatalf("step %d: expected %d lines, got %d", step, len(want), pt.LineCount())
+		}
+	}
+}
+
+// mergeLines joins line and the one after it, if there is one.
+func mergeLines(lines []string, line int) []string {
+	if line+1 >= len(lines) {
+		return lines
+	}
+	lines[line] += lines[line+1]
+	return append(lines[:line+1], lines[line+2:]...)
+}
+
+func TestPieceTable_Lines(t *testing.T) {
+	pt := NewPieceTableWithLines([][]rune{[]rune("one"), {}, []rune("three")})
+	pt.InsertRune(1, 0, 'x')
+	lines := pt.Lines()
+	if got := []string{string(lines[0]), string(lines[1]), string(lines[2])}; !reflect.DeepEqual(got, []string{"one", "x", "three"}) || len(lines) != 3 {
+		t.Fatalf("expected three lines, got %q", lines)
+	}
+	lines[0] = append(lines[0], '!')
+	if got := string(lines[1]); got != "x" {
+		t.Errorf("expected appending to a line to leave the next alone, got %q", got)
+	}
+	if got := string(pt.GetLine(0)); got != "one" {
+		t.Errorf("expected Lines to return a copy, got %q", got)
+	}
+}
+
+func TestTextBuffer_SetLines(t *testing.T) {
+	tb := makeBufferWithLines([]string{"a", "b"})
+	if tb.IsDirty() {
+		t.Errorf("expected a new buffer to be clean")
+	}
+	tb.SetLines([][]rune{[]rune("c")})
+	if got := getStringLines(tb); !reflect.DeepEqual(got, []string{"c"}) || !tb.IsDirty() {
+		t.Errorf("expected the content replaced and dirty, got %q dirty=%v", got, tb.IsDirty())
+	}
+	tb.InsertRune(0, 1, 'd')
+	if lines, _ := tb.Snapshot(true); string(lines[0]) != "cd" || tb.IsDirty() {
+		t.Errorf("expected a clean snapshot of the edit, got %q dirty=%v", lines, tb.IsDirty())
+	}
+}
+
+func benchmarkLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = "2025-01-01T00:00:00Z INFO request handled in 12ms path=/api/v1/items"
+	}
+	return lines
+}
+
+// lineSlices is TextBuffer as it was before the piece table, one slice of
+// runes per line, kept so the benchmarks can compare against it.
+type lineSlices struct {
+	lines [][]rune
+	mu    sync.RWMutex
+}
+
+func newLineSlices(lines []string) *lineSlices {
+	ls := &lineSlices{}
+	for _, line := range lines {
+		ls.lines = append(ls.lines, []rune(line))
+	}
+	return ls
+}
+
+func (ls *lineSlices) InsertRune(line, col int, ch rune) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	runes := ls.lines[line]
+	ls.lines[line] = append(runes[:col], append([]rune{ch}, runes[col:]...)...)
+}
+
+func (ls *lineSlices) InsertNewLine(line, col int) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	current := ls.lines[line]
+	after := append([]rune{}, current[col:]...)
+	ls.lines[line] = current[:col]
+	ls.lines = append(ls.lines[:line+1], append([][]rune{after}, ls.lines[line+1:]...)...)
+}
+
+func (ls *lineSlices) GetLine(line int) []rune {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.lines[line]
+}
+
+func BenchmarkLineSlices_InsertRune(b *testing.B) {
+	ls := newLineSlices(benchmarkLines(50000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ls.InsertRune(25000, 10+i%50, 'x')
+	}
+}
+
+func BenchmarkTextBuffer_InsertRune(b *testing.B) {
+	tb := makeBufferWithLines(benchmarkLines(50000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tb.InsertRune(25000, 10+i%50, 'x')
+	}
+}
+
+func BenchmarkPieceTable_InsertRune(b *testing.B) {
+	lines := benchmarkLines(50000)
+	pt := NewPieceTable([]byte(strings.Join(lines, "\n")))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pt.InsertRune(25000, 10+i%50, 'x')
+	}
+}
+
+func BenchmarkLineSlices_InsertNewLine(b *testing.B) {
+	ls := newLineSlices(benchmarkLines(50000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ls.InsertNewLine(25000, 10)
+	}
+}
+
+func BenchmarkTextBuffer_InsertNewLine(b *testing.B) {
+	tb := makeBufferWithLines(benchmarkLines(50000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tb.InsertNewLine(25000, 10)
+	}
+}
+
+func BenchmarkPieceTable_InsertNewLine(b *testing.B) {
+	lines := benchmarkLines(50000)
+	pt := NewPieceTable([]byte(strings.Join(lines, "\n")))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pt.InsertNewLine(25000, 10)
+	}
+}
+
+func BenchmarkLineSlices_GetLine(b *testing.B) {
+	ls := newLineSlices(benchmarkLines(50000))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ls.GetLine(i % 50000)
+	}
+}
+
+func BenchmarkPieceTable_GetLine(b *testing.B) {
+	pt := NewPieceTable([]byte(strings.Join(benchmarkLines(50000), "\n")))
+	for i := 0; i < 1000; i++ {
+		pt.InsertRune(i*50, 10, 'x') // fragment the table
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pt.GetLine(i % 50000)
+	}
+}
+
+func BenchmarkLineSlices_Load(b *testing.B) {
+	lines := benchmarkLines(50000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newLineSlices(lines)
+	}
+}
+
+func BenchmarkPieceTable_Load(b *testing.B) {
+	content := []byte(strings.Join(benchmarkLines(50000), "\n"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewPieceTable(content)
+	}
+}
@@ -1,62 +1,406 @@
 package editor
 
+import (
+	"time"
+	"unicode"
+)
+
+// DefaultGroupTimeout is the pause after which typing starts a new undo step.
+const DefaultGroupTimeout = time.Second
+
+const (
+	ActionInsert = "insert"
+	ActionDelete = "delete"
+	ActionSet    = "set"
+)
+
+// EditAction is one reversible change to a buffer: Text inserted at, or
+// deleted from, Line/Col. A '\n' in Text stands for a line break.
+//
+// An ActionSet leaves the text alone and records a setting of the file,
+// such as its line ending, changed from Old to Text; undo and redo hand
+// the value to restore to UndoManager.OnSet.
+type EditAction struct {
+	Line, Col int
+	Text      []rune
+	Action    string // "insert", "delete" or "set"
+	Name      string // the setting changed by an ActionSet
+	Old       []rune
+}
+
 type EditState struct {
 	Lines [][]rune
 }
 
+// undoEntry is a single undo step. Entries recorded through Apply hold
+// the edit actions; entries recorded through Push hold full snapshots of
+// the buffer before and, once undone, after the step.
+type undoEntry struct {
+	actions  []EditAction
+	snapshot *EditState
+	redoSnap *EditState
+	before   CursorPointer // cursor before the first action
+	after    CursorPointer // cursor after the last action
+}
+
+// UndoManager keeps the edit history as a tree: undoing and then making a
+// new edit starts a new branch instead of discarding the undone steps.
+// See undo_tree.go for moving between branches and through time.
 type UndoManager struct {
-	undoStack []EditState
-	redoStack []EditState
+	root      *undoNode
+	nodes     []*undoNode // every node, indexed by seq
+	undoStack []*undoNode // path from root to the current state
+
+	// GroupTimeout is how long a pause in typing may last before the
+	// next edit starts a new undo step.
+	GroupTimeout time.Duration
+
+	// OnSet is called with the value a setting goes back to when undo or
+	// redo passes an ActionSet.
+	OnSet func(name, value string)
+
+	groupDepth int       // nesting of BeginGroup calls
+	open       bool      // whether the last entry may still be extended
+	lastEdit   time.Time // when the last action was applied
+	now        func() time.Time
 }
 
 func NewUndoManager() *UndoManager {
+	root := &undoNode{time: time.Now()}
 	return &UndoManager{
-		undoStack: make([]EditState, 0),
-		redoStack: make([]EditState, 0),
+		root:         root,
+		nodes:        []*undoNode{root},
+		undoStack:    make([]*undoNode, 0),
+		GroupTimeout: DefaultGroupTimeout,
+		now:          time.Now,
 	}
 }
 
 type Undo interface {
-	Undo(buffer *TextBuffer)
+	Undo(buffer *TextBuffer) (CursorPointer, bool)
 	Push(buffer *TextBuffer)
-	Redo(buffer *TextBuffer)
+	Redo(buffer *TextBuffer) (CursorPointer, bool)
+	Apply(buffer Buffer, cursor *CursorPointer, action EditAction)
+	BeginGroup()
+	EndGroup()
+	Boundary()
+}
+
+// Apply performs action on buffer, moves cursor to where the edit ends
+// and records both for undo. Consecutive inserts or deletes on the same
+// line are coalesced into one undo step until a newline, a pause longer
+// than GroupTimeout, the start of a new word or an explicit Boundary.
+// Inside BeginGroup/EndGroup every action joins the same step. An
+// ActionSet only records the change, which the caller has made, and
+// leaves the cursor where it is.
+func (um *UndoManager) Apply(buffer Buffer, cursor *CursorPointer, action EditAction) {
+	before := *cursor
+	action.apply(buffer)
+	if action.Action != ActionSet {
+		cursor.SetPosition(action.endCol(), action.endLine(), buffer)
+	}
+	now := um.now()
+
+	if um.canExtend(action, now) {
+		cur := um.current()
+		last := &cur.entry
+		prev := &last.actions[len(last.actions)-1]
+		if merged, ok := prev.merge(action, um.groupDepth > 0); ok {
+			*prev = merged
+		} else {
+			last.actions = append(last.actions, action)
+		}
+		last.after = *cursor
+		cur.time = now
+	} else {
+		um.addNode(undoEntry{
+			actions: []EditAction{action},
+			before:  before,
+			after:   *cursor,
+		}, now)
+	}
+	um.lastEdit = now
+	um.open = um.groupDepth > 0 || !action.hasNewline()
 }
 
+// BeginGroup starts a transaction: every action applied until the
+// matching EndGroup is undone and redone as a single step. Groups nest.
+func (um *UndoManager) BeginGroup() {
+	if um.groupDepth == 0 {
+		um.open = false
+	}
+	um.groupDepth++
+}
+
+func (um *UndoManager) EndGroup() {
+	if um.groupDepth == 0 {
+		return
+	}
+	um.groupDepth--
+	if um.groupDepth == 0 {
+		um.open = false
+	}
+}
+
+// Boundary closes the current undo step, e.g. after a cursor jump, so
+// the next edit is undone separately.
+func (um *UndoManager) Boundary() {
+	if um.groupDepth == 0 {
+		um.open = false
+	}
+}
+
+func (um *UndoManager) canExtend(action EditAction, now time.Time) bool {
+	cur := um.current()
+	if !um.open || cur == um.root || len(cur.children) > 0 {
+		return false
+	}
+	if cur.entry.snapshot != nil {
+		return false
+	}
+	if um.groupDepth > 0 {
+		return true
+	}
+	if now.Sub(um.lastEdit) > um.GroupTimeout {
+		return false
+	}
+	_, ok := cur.entry.actions[len(cur.entry.actions)-1].merge(action, false)
+	return ok
+}
+
+// Push records a snapshot of the whole buffer, for callers that replace
+// its content with SetLines. Prefer Apply, which only stores the change.
 func (um *UndoManager) Push(buffer *TextBuffer) {
-	lines := buffer.Lines
-	copyLines := copyBuffer(lines)
-	um.undoStack = append(um.undoStack, EditState{copyLines})
-	um.redoStack = nil
+	um.addNode(undoEntry{snapshot: &EditState{buffer.Lines()}}, um.now())
+	um.open = false
 }
 
-func (um *UndoManager) Undo(buffer *TextBuffer) {
+// Undo reverts the last step and returns the cursor position from before
+// it was made. ok is false when there is nothing to undo. Steps recorded
+// with Push do not track the cursor and return the zero position.
+func (um *UndoManager) Undo(buffer *TextBuffer) (pos CursorPointer, ok bool) {
 	if len(um.undoStack) == 0 {
-		return
+		return pos, false
+	}
+	um.open = false
+	return um.undoStep(buffer), true
+}
+
+// Redo re-applies the most recently visited branch below the current
+// state and returns the cursor position from right after it was made.
+func (um *UndoManager) Redo(buffer *TextBuffer) (pos CursorPointer, ok bool) {
+	cur := um.current()
+	if len(cur.children) == 0 {
+		return pos, false
+	}
+	um.open = false
+	return um.redoStep(buffer, cur.children[cur.redo]), true
+}
+
+func (um *UndoManager) current() *undoNode {
+	if len(um.undoStack) == 0 {
+		return um.root
+	}
+	return um.undoStack[len(um.undoStack)-1]
+}
+
+// addNode records entry as a new child of the current state and makes it
+// the current state.
+func (um *UndoManager) addNode(entry undoEntry, now time.Time) {
+	cur := um.current()
+	node := &undoNode{
+		entry:  entry,
+		seq:    len(um.nodes),
+		time:   now,
+		parent: cur,
 	}
-	lastState := um.undoStack[len(um.undoStack)-1]
-	currentState := copyBuffer(buffer.Lines)
-	buffer.Lines = lastState.Lines
+	cur.children = append(cur.children, node)
+	cur.redo = len(cur.children) - 1
+	um.nodes = append(um.nodes, node)
+	um.undoStack = append(um.undoStack, node)
+}
+
+func (um *UndoManager) undoStep(buffer *TextBuffer) CursorPointer {
+	node := um.undoStack[len(um.undoStack)-1]
 	um.undoStack = um.undoStack[:len(um.undoStack)-1]
-	um.redoStack = append(um.redoStack, EditState{currentState})
+	node.entry.revert(buffer, um.OnSet)
+	node.parent.redo = node.index()
+	buffer.SetDirty(true)
+	return node.entry.before
+}
+
+func (um *UndoManager) redoStep(buffer *TextBuffer, node *undoNode) CursorPointer {
+	node.entry.replay(buffer, um.OnSet)
+	node.parent.redo = node.index()
+	um.undoStack = append(um.undoStack, node)
 	buffer.SetDirty(true)
+	return node.entry.after
 }
-func (um *UndoManager) Redo(buffer *TextBuffer) {
-	if len(um.redoStack) == 0 {
+
+func (e *undoEntry) revert(buffer *TextBuffer, onSet func(name, value string)) {
+	if e.snapshot != nil {
+		e.redoSnap = &EditState{buffer.Lines()}
+		buffer.SetLines(e.snapshot.Lines)
 		return
 	}
-	lastState := um.redoStack[len(um.redoStack)-1]
-	currentState := copyBuffer(buffer.Lines)
-	buffer.Lines = lastState.Lines
-	um.undoStack = append(um.undoStack, EditState{currentState})
-	um.redoStack = um.redoStack[:len(um.redoStack)-1]
-	buffer.SetDirty(true)
+	for i := len(e.actions) - 1; i >= 0; i-- {
+		e.actions[i].inverse().perform(buffer, onSet)
+	}
+}
+
+func (e *undoEntry) replay(buffer *TextBuffer, onSet func(name, value string)) {
+	if e.snapshot != nil {
+		if e.redoSnap != nil {
+			buffer.SetLines(e.redoSnap.Lines)
+		}
+		return
+	}
+	for _, action := range e.actions {
+		action.perform(buffer, onSet)
+	}
+}
+
+// perform is apply for undo and redo, which also restore settings.
+func (a EditAction) perform(buffer Buffer, onSet func(name, value string)) {
+	if a.Action == ActionSet {
+		if onSet != nil {
+			onSet(a.Name, string(a.Text))
+		}
+		return
+	}
+	a.apply(buffer)
+}
+
+// size returns the number of runes held by the entry.
+func (e undoEntry) size() int {
+	n := 0
+	for _, action := range e.actions {
+		n += len(action.Text) + len(action.Old)
+	}
+	for _, snap := range []*EditState{e.snapshot, e.redoSnap} {
+		if snap == nil {
+			continue
+		}
+		for _, line := range snap.Lines {
+			n += len(line)
+		}
+	}
+	return n
+}
+
+// size returns the number of runes held by the whole history.
+func (um *UndoManager) size() int {
+	n := 0
+	for _, node := range um.nodes {
+		n += node.entry.size()
+	}
+	return n
+}
+
+func (a EditAction) inverse() EditAction {
+	switch a.Action {
+	case ActionInsert:
+		a.Action = ActionDelete
+	case ActionDelete:
+		a.Action = ActionInsert
+	case ActionSet:
+		a.Old, a.Text = a.Text, a.Old
+	}
+	return a
+}
+
+// merge combines a and next into one action when next continues a on the
+// same line: typing after an insert, or backspace/delete next to a delete.
+// Unless grouped, typing the first letter of a new word does not merge.
+func (a EditAction) merge(next EditAction, grouped bool) (EditAction, bool) {
+	if a.Action != next.Action || a.Line != next.Line || a.hasNewline() || next.hasNewline() {
+		return a, false
+	}
+	switch a.Action {
+	case ActionInsert:
+		if next.Col != a.Col+len(a.Text) {
+			return a, false
+		}
+		if !grouped && len(a.Text) > 0 && len(next.Text) > 0 &&
+			unicode.IsSpace(a.Text[len(a.Text)-1]) && !unicode.IsSpace(next.Text[0]) {
+			return a, false
+		}
+		a.Text = append(append([]rune{}, a.Text...), next.Text...)
+		return a, true
+	case ActionDelete:
+		if next.Col+len(next.Text) == a.Col { // backspace
+			a.Text = append(append([]rune{}, next.Text...), a.Text...)
+			a.Col = next.Col
+			return a, true
+		}
+		if next.Col == a.Col { // forward delete
+			a.Text = append(append([]rune{}, a.Text...), next.Text...)
+			return a, true
+		}
+	}
+	return a, false
+}
+
+// endLine and endCol give the position right after the action: the end of
+// the inserted text, or where deleted text used to start.
+func (a EditAction) endLine() int {
+	if a.Action == ActionDelete {
+		return a.Line
+	}
+	line := a.Line
+	for _, r := range a.Text {
+		if r == '\n' {
+			line++
+		}
+	}
+	return line
+}
+
+func (a EditAction) endCol() int {
+	if a.Action == ActionDelete {
+		return a.Col
+	}
+	col := a.Col
+	for _, r := range a.Text {
+		if r == '\n' {
+			col = 0
+		} else {
+			col++
+		}
+	}
+	return col
+}
+
+func (a EditAction) hasNewline() bool {
+	for _, r := range a.Text {
+		if r == '\n' {
+			return true
+		}
+	}
+	return false
 }
 
-func copyBuffer(buffer [][]rune) [][]rune {
-	copied := make([][]rune, len(buffer))
-	for i, line := range buffer {
-		copied[i] = make([]rune, len(line))
-		copy(copied[i], line)
+func (a EditAction) apply(buffer Buffer) {
+	line, col := a.Line, a.Col
+	switch a.Action {
+	case ActionInsert:
+		for _, r := range a.Text {
+			if r == '\n' {
+				buffer.InsertNewLine(line, col)
+				line++
+				col = 0
+			} else {
+				buffer.InsertRune(line, col, r)
+				col++
+			}
+		}
+	case ActionDelete:
+		for _, r := range a.Text {
+			if r == '\n' {
+				buffer.MergeLine(line)
+			} else {
+				buffer.DeleteRune(line, col+1, r)
+			}
+		}
 	}
-	return copied
 }
@@ -0,0 +1,132 @@
+package editor
+
+import (
+	"fmt"
+	"time"
+)
+
+// UndoHistory is the serialisable form of an UndoManager's tree, used to
+// keep undo history across editor sessions.
+type UndoHistory struct {
+	Current int           `json:"current"`
+	Nodes   []HistoryNode `json:"nodes"`
+}
+
+// HistoryNode is one undoNode. Nodes are stored in creation order, so a
+// node's Parent always comes before it; the root is Nodes[0].
+type HistoryNode struct {
+	Parent   int             `json:"parent"`
+	Time     time.Time       `json:"time"`
+	Redo     int             `json:"redo"`
+	Actions  []HistoryAction `json:"actions,omitempty"`
+	Snapshot []string        `json:"snapshot,omitempty"`
+	RedoSnap []string        `json:"redoSnap,omitempty"`
+	Before   [2]int          `json:"before"` // cursor X, Y
+	After    [2]int          `json:"after"`
+}
+
+type HistoryAction struct {
+	Line   int    `json:"line"`
+	Col    int    `json:"col"`
+	Text   string `json:"text"`
+	Action string `json:"action"`
+	Name   string `json:"name,omitempty"`
+	Old    string `json:"old,omitempty"`
+}
+
+// History returns a copy of the undo tree that can be encoded as JSON.
+func (um *UndoManager) History() UndoHistory {
+	h := UndoHistory{Current: um.CurrentSeq()}
+	for _, node := range um.nodes {
+		hn := HistoryNode{
+			Parent:   -1,
+			Time:     node.time,
+			Redo:     node.redo,
+			Snapshot: linesToStrings(node.entry.snapshot),
+			RedoSnap: linesToStrings(node.entry.redoSnap),
+			Before:   [2]int{node.entry.before.X, node.entry.before.Y},
+			After:    [2]int{node.entry.after.X, node.entry.after.Y},
+		}
+		if node.parent != nil {
+			hn.Parent = node.parent.seq
+		}
+		for _, a := range node.entry.actions {
+			hn.Actions = append(hn.Actions, HistoryAction{
+				Line: a.Line, Col: a.Col, Text: string(a.Text), Action: a.Action,
+				Name: a.Name, Old: string(a.Old),
+			})
+		}
+		h.Nodes = append(h.Nodes, hn)
+	}
+	return h
+}
+
+// NewUndoManagerFromHistory rebuilds an UndoManager from h. The buffer it
+// is used with must hold the content of state h.Current.
+func NewUndoManagerFromHistory(h UndoHistory) (*UndoManager, error) {
+	if len(h.Nodes) == 0 {
+		return nil, fmt.Errorf("undo history has no root")
+	}
+	if h.Current < 0 || h.Current >= len(h.Nodes) {
+		return nil, fmt.Errorf("undo history current state %d out of range", h.Current)
+	}
+
+	um := NewUndoManager()
+	um.nodes = make([]*undoNode, len(h.Nodes))
+	for i, hn := range h.Nodes {
+		node := &undoNode{seq: i, time: hn.Time, redo: hn.Redo}
+		node.entry = undoEntry{
+			snapshot: stringsToLines(hn.Snapshot),
+			redoSnap: stringsToLines(hn.RedoSnap),
+			before:   CursorPointer{X: hn.Before[0], Y: hn.Before[1]},
+			after:    CursorPointer{X: hn.After[0], Y: hn.After[1]},
+		}
+		for _, a := range hn.Actions {
+			node.entry.actions = append(node.entry.actions, EditAction{
+				Line: a.Line, Col: a.Col, Text: []rune(a.Text), Action: a.Action,
+				Name: a.Name, Old: []rune(a.Old),
+			})
+		}
+		if i > 0 {
+			if hn.Parent < 0 || hn.Parent >= i {
+				return nil, fmt.Errorf("undo history node %d has invalid parent %d", i, hn.Parent)
+			}
+			node.parent = um.nodes[hn.Parent]
+			node.parent.children = append(node.parent.children, node)
+		}
+		um.nodes[i] = node
+	}
+	for _, node := range um.nodes {
+		if node.redo < 0 || node.redo >= max(len(node.children), 1) {
+			node.redo = 0
+		}
+	}
+
+	um.root = um.nodes[0]
+	for n := um.nodes[h.Current]; n.parent != nil; n = n.parent {
+		um.undoStack = append([]*undoNode{n}, um.undoStack...)
+	}
+	return um, nil
+}
+
+func linesToStrings(state *EditState) []string {
+	if state == nil {
+		return nil
+	}
+	out := make([]string, len(state.Lines))
+	for i, line := range state.Lines {
+		out[i] = string(line)
+	}
+	return out
+}
+
+func stringsToLines(lines []string) *EditState {
+	if lines == nil {
+		return nil
+	}
+	state := &EditState{Lines: make([][]rune, len(lines))}
+	for i, line := range lines {
+		state.Lines[i] = []rune(line)
+	}
+	return state
+}
@@ -0,0 +1,59 @@
+package editor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUndoHistory_RoundTrip(t *testing.T) {
+	um := NewUndoManager()
+	fakeClock(um)
+	buffer := makeBufferWithLines([]string{""})
+
+	typeText(um, buffer, 0, 0, "first")
+	um.Undo(buffer)
+	typeText(um, buffer, 0, 0, "second")
+	um.Apply(buffer, NewCursor(6, 0), EditAction{Line: 0, Col: 6, Text: []rune{'\n'}, Action: ActionInsert})
+
+	data, err := json.Marshal(um.History())
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var h UndoHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	restored, err := NewUndoManagerFromHistory(h)
+	if err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	if restored.CurrentSeq() != um.CurrentSeq() || len(restored.Branches()) != 2 {
+		t.Fatalf("restored tree mismatch: seq %d, %d branches", restored.CurrentSeq(), len(restored.Branches()))
+	}
+
+	pos, _ := restored.Undo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"second"}) {
+		t.Errorf("undo after restore failed, got %v", got)
+	}
+	if pos.X != 6 || pos.Y != 0 {
+		t.Errorf("expected restored cursor (6,0), got (%d,%d)", pos.X, pos.Y)
+	}
+
+	restored.GoTo(buffer, 1)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"first"}) {
+		t.Errorf("restored branch missing, got %v", got)
+	}
+}
+
+func TestUndoHistory_Invalid(t *testing.T) {
+	if _, err := NewUndoManagerFromHistory(UndoHistory{}); err == nil {
+		t.Errorf("expected error for empty history")
+	}
+
+	h := UndoHistory{Current: 1, Nodes: []HistoryNode{{Parent: -1}, {Parent: 5}}}
+	if _, err := NewUndoManagerFromHistory(h); err == nil {
+		t.Errorf("expected error for bad parent")
+	}
+}
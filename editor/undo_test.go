@@ -3,20 +3,20 @@ package editor
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func makeBufferWithLines(lines []string) *TextBuffer {
-	tb := NewTextBuffer()
-	tb.Lines = [][]rune{}
-	for _, line := range lines {
-		tb.Lines = append(tb.Lines, []rune(line))
+	runes := make([][]rune, len(lines))
+	for i, line := range lines {
+		runes[i] = []rune(line)
 	}
-	return tb
+	return NewTextBufferWithLines(runes)
 }
 
 func getStringLines(tb *TextBuffer) []string {
 	lines := []string{}
-	for _, r := range tb.Lines {
+	for _, r := range tb.Lines() {
 		lines = append(lines, string(r))
 	}
 	return lines
@@ -41,7 +41,7 @@ func TestUndoManager_UndoRedo(t *testing.T) {
 	um.Push(buffer)
 
 	// Simulate edit
-	buffer.Lines[0] = []rune("changed")
+	buffer.SetLines([][]rune{[]rune("changed")})
 
 	// Undo should bring back "line1"
 	um.Undo(buffer)
@@ -73,11 +73,11 @@ func TestUndoManager_Redo(t *testing.T) {
 	um := NewUndoManager()
 	buffer := makeBufferWithLines([]string{"original"})
 
-	um.Push(buffer)                    // Push original
-	buffer.Lines[0] = []rune("edited") // Edit 1
+	um.Push(buffer)                             // Push original
+	buffer.SetLines([][]rune{[]rune("edited")}) // Edit 1
 
-	um.Push(buffer)                   // Push edited
-	buffer.Lines[0] = []rune("final") // Edit 2
+	um.Push(buffer)                            // Push edited
+	buffer.SetLines([][]rune{[]rune("final")}) // Edit 2
 
 	um.Undo(buffer) // back to "edited"
 	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"edited"}) {
@@ -89,3 +89,234 @@ func TestUndoManager_Redo(t *testing.T) {
 		t.Errorf("Redo to final failed. Got %v", got)
 	}
 }
+
+func TestUndoManager_ApplyUndoRedo(t *testing.T) {
+	um := NewUndoManager()
+	buffer := makeBufferWithLines([]string{"ac", "d"})
+
+	um.Apply(buffer, NewCursor(0, 0), EditAction{Line: 0, Col: 1, Text: []rune("b"), Action: ActionInsert})
+	um.Apply(buffer, NewCursor(0, 0), EditAction{Line: 0, Col: 3, Text: []rune("\n"), Action: ActionDelete})
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"abcd"}) {
+		t.Fatalf("Apply failed. Got %v", got)
+	}
+
+	um.Undo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"abc", "d"}) {
+		t.Errorf("Undo of merge failed. Got %v", got)
+	}
+	um.Undo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"ac", "d"}) {
+		t.Errorf("Undo of insert failed. Got %v", got)
+	}
+
+	um.Redo(buffer)
+	um.Redo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"abcd"}) {
+		t.Errorf("Redo failed. Got %v", got)
+	}
+}
+
+func TestUndoManager_MultiLineAction(t *testing.T) {
+	um := NewUndoManager()
+	buffer := makeBufferWithLines([]string{"start end"})
+
+	um.Apply(buffer, NewCursor(0, 0), EditAction{Line: 0, Col: 6, Text: []rune("one\ntwo\n"), Action: ActionInsert})
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"start one", "two", "end"}) {
+		t.Fatalf("multi-line insert failed. Got %v", got)
+	}
+
+	um.Undo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"start end"}) {
+		t.Errorf("multi-line undo failed. Got %v", got)
+	}
+}
+
+// TestUndoManager_MemoryProportionalToEdits checks that history size
+// depends on how much was typed, not on how big the file is.
+func TestUndoManager_MemoryProportionalToEdits(t *testing.T) {
+	sizes := []int{}
+	for _, lineCount := range []int{10, 50000} {
+		lines := make([]string, lineCount)
+		for i := range lines {
+			lines[i] = "some fairly ordinary line of text"
+		}
+		buffer := makeBufferWithLines(lines)
+		um := NewUndoManager()
+
+		for i := 0; i < 100; i++ {
+			um.Apply(buffer, NewCursor(0, 0), EditAction{Line: 5, Col: i, Text: []rune{'x'}, Action: ActionInsert})
+		}
+		um.Undo(buffer)
+		sizes = append(sizes, um.size())
+	}
+
+	if sizes[0] != 100 || sizes[1] != 100 {
+		t.Errorf("expected history of 100 runes regardless of file size, got %v", sizes)
+	}
+}
+
+func typeText(um *UndoManager, buffer *TextBuffer, line, col int, text string) {
+	for _, r := range text {
+		um.Apply(buffer, NewCursor(0, 0), EditAction{Line: line, Col: col, Text: []rune{r}, Action: ActionInsert})
+		col++
+	}
+}
+
+func fakeClock(um *UndoManager) *time.Time {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	um.now = func() time.Time { return now }
+	um.root.time = now
+	return &now
+}
+
+func TestUndoManager_CoalescesTyping(t *testing.T) {
+	um := NewUndoManager()
+	fakeClock(um)
+	buffer := makeBufferWithLines([]string{""})
+
+	typeText(um, buffer, 0, 0, "hello world")
+	if len(um.undoStack) != 2 {
+		t.Fatalf("expected one step per word, got %d", len(um.undoStack))
+	}
+
+	um.Undo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"hello "}) {
+		t.Errorf("Undo of word failed. Got %v", got)
+	}
+	um.Undo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("Undo of first word failed. Got %v", got)
+	}
+}
+
+func TestUndoManager_CoalescesBackspace(t *testing.T) {
+	um := NewUndoManager()
+	fakeClock(um)
+	buffer := makeBufferWithLines([]string{"abcdef"})
+
+	for col := 6; col > 2; col-- {
+		r := buffer.GetLine(0)[col-1]
+		um.Apply(buffer, NewCursor(0, 0), EditAction{Line: 0, Col: col - 1, Text: []rune{r}, Action: ActionDelete})
+	}
+	if len(um.undoStack) != 1 {
+		t.Fatalf("expected backspaces to form one step, got %d", len(um.undoStack))
+	}
+
+	um.Undo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"abcdef"}) {
+		t.Errorf("Undo of backspaces failed. Got %v", got)
+	}
+}
+
+func TestUndoManager_GroupBreaks(t *testing.T) {
+	um := NewUndoManager()
+	now := fakeClock(um)
+	buffer := makeBufferWithLines([]string{""})
+
+	typeText(um, buffer, 0, 0, "ab")
+	*now = now.Add(2 * um.GroupTimeout)
+	typeText(um, buffer, 0, 2, "cd")
+	um.Boundary()
+	typeText(um, buffer, 0, 4, "ef")
+	um.Apply(buffer, NewCursor(0, 0), EditAction{Line: 0, Col: 6, Text: []rune{'\n'}, Action: ActionInsert})
+	typeText(um, buffer, 1, 0, "gh")
+
+	// "ab", "cd", "ef", newline, "gh"
+	if len(um.undoStack) != 5 {
+		t.Errorf("expected 5 undo steps, got %d", len(um.undoStack))
+	}
+}
+
+func TestUndoManager_ExplicitGroup(t *testing.T) {
+	um := NewUndoManager()
+	fakeClock(um)
+	buffer := makeBufferWithLines([]string{"x"})
+
+	um.BeginGroup()
+	typeText(um, buffer, 0, 1, "one")
+	um.Apply(buffer, NewCursor(0, 0), EditAction{Line: 0, Col: 4, Text: []rune{'\n'}, Action: ActionInsert})
+	typeText(um, buffer, 1, 0, "two")
+	um.EndGroup()
+	typeText(um, buffer, 1, 3, "!")
+
+	if len(um.undoStack) != 2 {
+		t.Fatalf("expected group plus one step, got %d", len(um.undoStack))
+	}
+	um.Undo(buffer)
+	um.Undo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"x"}) {
+		t.Errorf("Undo of group failed. Got %v", got)
+	}
+	um.Redo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"xone", "two"}) {
+		t.Errorf("Redo of group failed. Got %v", got)
+	}
+}
+
+func TestUndoManager_RestoresCursor(t *testing.T) {
+	um := NewUndoManager()
+	fakeClock(um)
+	buffer := makeBufferWithLines([]string{"foo", "bar"})
+	cursor := NewCursor(3, 0)
+
+	for _, r := range "ly" {
+		um.Apply(buffer, cursor, EditAction{Line: cursor.Y, Col: cursor.X, Text: []rune{r}, Action: ActionInsert})
+	}
+	if cursor.X != 5 || cursor.Y != 0 {
+		t.Fatalf("expected cursor at end of insert (5,0), got (%d,%d)", cursor.X, cursor.Y)
+	}
+
+	cursor.SetPosition(0, 1, buffer) // wander off before undoing
+	pos, ok := um.Undo(buffer)
+	if !ok || pos.X != 3 || pos.Y != 0 {
+		t.Errorf("expected undo to return (3,0), got (%d,%d) ok=%v", pos.X, pos.Y, ok)
+	}
+
+	pos, ok = um.Redo(buffer)
+	if !ok || pos.X != 5 || pos.Y != 0 {
+		t.Errorf("expected redo to return (5,0), got (%d,%d) ok=%v", pos.X, pos.Y, ok)
+	}
+
+	um2 := NewUndoManager()
+	if _, ok := um2.Undo(buffer); ok {
+		t.Errorf("expected ok=false on empty undo")
+	}
+}
+
+func TestUndoManager_RestoresCursorAcrossLines(t *testing.T) {
+	um := NewUndoManager()
+	buffer := makeBufferWithLines([]string{"ab", "cd"})
+	cursor := NewCursor(0, 1)
+
+	// backspace at the start of line 1 joins it onto line 0
+	um.Apply(buffer, cursor, EditAction{Line: 0, Col: 2, Text: []rune{'\n'}, Action: ActionDelete})
+	if cursor.X != 2 || cursor.Y != 0 {
+		t.Fatalf("expected cursor at join point (2,0), got (%d,%d)", cursor.X, cursor.Y)
+	}
+
+	pos, _ := um.Undo(buffer)
+	if pos.X != 0 || pos.Y != 1 {
+		t.Errorf("expected undo to return (0,1), got (%d,%d)", pos.X, pos.Y)
+	}
+}
+
+func TestUndoManager_Set(t *testing.T) {
+	um := NewUndoManager()
+	buffer := makeBufferWithLines([]string{"ab"})
+	cursor := NewCursor(1, 0)
+	var got []string
+	um.OnSet = func(name, value string) { got = append(got, name+"="+value) }
+
+	um.Apply(buffer, cursor, EditAction{Action: ActionSet, Name: "mode", Old: []rune("old"), Text: []rune("new")})
+	if cursor.X != 1 || cursor.Y != 0 {
+		t.Errorf("expected a setting to leave the cursor at (1,0), got (%d,%d)", cursor.X, cursor.Y)
+	}
+	um.Undo(buffer)
+	um.Redo(buffer)
+	if want := []string{"mode=old", "mode=new"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected OnSet calls %v, got %v", want, got)
+	}
+	if lines := getStringLines(buffer); !reflect.DeepEqual(lines, []string{"ab"}) {
+		t.Errorf("expected the text untouched, got %v", lines)
+	}
+}
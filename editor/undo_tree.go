@@ -0,0 +1,140 @@
+package editor
+
+import (
+	"sort"
+	"time"
+)
+
+// undoNode is one buffer state in the undo tree. entry turns the parent's
+// state into this one.
+type undoNode struct {
+	entry    undoEntry
+	seq      int // creation order, 0 is the root
+	time     time.Time
+	parent   *undoNode
+	children []*undoNode
+	redo     int // child followed by Redo
+}
+
+func (n *undoNode) index() int {
+	for i, child := range n.parent.children {
+		if child == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// UndoBranch describes the tip of one branch of the undo tree.
+type UndoBranch struct {
+	Seq    int
+	Time   time.Time
+	Depth  int  // number of steps from the unedited buffer
+	Active bool // whether Redo from the current state leads here
+}
+
+// CurrentSeq returns the sequence number of the current state; 0 is the
+// buffer before any recorded edit.
+func (um *UndoManager) CurrentSeq() int {
+	return um.current().seq
+}
+
+// LastSeq returns the sequence number of the newest state.
+func (um *UndoManager) LastSeq() int {
+	return len(um.nodes) - 1
+}
+
+// Branches lists the tip of every branch, oldest first.
+func (um *UndoManager) Branches() []UndoBranch {
+	active := um.current()
+	for len(active.children) > 0 {
+		active = active.children[active.redo]
+	}
+
+	branches := []UndoBranch{}
+	for _, node := range um.nodes {
+		if len(node.children) > 0 {
+			continue
+		}
+		depth := 0
+		for n := node; n.parent != nil; n = n.parent {
+			depth++
+		}
+		branches = append(branches, UndoBranch{
+			Seq:    node.seq,
+			Time:   node.time,
+			Depth:  depth,
+			Active: node == active,
+		})
+	}
+	return branches
+}
+
+// GoTo moves the buffer to the state with the given sequence number,
+// undoing back to the common ancestor and redoing down the target's
+// branch. It returns the cursor position of the last step taken.
+func (um *UndoManager) GoTo(buffer *TextBuffer, seq int) (pos CursorPointer, ok bool) {
+	if seq < 0 || seq >= len(um.nodes) {
+		return pos, false
+	}
+	target := um.nodes[seq]
+	um.open = false
+
+	onPath := map[*undoNode]bool{}
+	path := []*undoNode{}
+	for n := target; n != nil; n = n.parent {
+		onPath[n] = true
+		path = append(path, n)
+	}
+
+	moved := false
+	for !onPath[um.current()] {
+		pos = um.undoStep(buffer)
+		moved = true
+	}
+	cur := um.current()
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].parent != cur {
+			continue
+		}
+		pos = um.redoStep(buffer, path[i])
+		cur = path[i]
+		moved = true
+	}
+	return pos, moved
+}
+
+// StepBack and StepForward move to the previous or next state in the
+// order they were created, crossing branches (like vim's g- and g+).
+func (um *UndoManager) StepBack(buffer *TextBuffer) (CursorPointer, bool) {
+	return um.GoTo(buffer, um.CurrentSeq()-1)
+}
+
+func (um *UndoManager) StepForward(buffer *TextBuffer) (CursorPointer, bool) {
+	return um.GoTo(buffer, um.CurrentSeq()+1)
+}
+
+// Earlier moves to the buffer state as it was d before the current one
+// (like vim's :earlier).
+func (um *UndoManager) Earlier(buffer *TextBuffer, d time.Duration) (CursorPointer, bool) {
+	return um.GoTo(buffer, um.seqAt(um.current().time.Add(-d)))
+}
+
+// Later moves to the buffer state as it was d after the current one
+// (like vim's :later), stopping at the newest state.
+func (um *UndoManager) Later(buffer *TextBuffer, d time.Duration) (CursorPointer, bool) {
+	return um.GoTo(buffer, um.seqAt(um.current().time.Add(d)))
+}
+
+// seqAt returns the newest state created at or before t.
+func (um *UndoManager) seqAt(t time.Time) int {
+	// nodes are appended in creation order, but grouped typing bumps a
+	// node's time, so sort a copy by time before searching.
+	nodes := append([]*undoNode{}, um.nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].time.Before(nodes[j].time) })
+	i := sort.Search(len(nodes), func(i int) bool { return nodes[i].time.After(t) })
+	if i == 0 {
+		return 0
+	}
+	return nodes[i-1].seq
+}
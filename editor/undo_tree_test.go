@@ -0,0 +1,110 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUndoTree_KeepsUndoneBranch(t *testing.T) {
+	um := NewUndoManager()
+	fakeClock(um)
+	buffer := makeBufferWithLines([]string{""})
+
+	typeText(um, buffer, 0, 0, "abc")
+	um.Undo(buffer)
+	typeText(um, buffer, 0, 0, "x")
+
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"x"}) {
+		t.Fatalf("expected new branch content, got %v", got)
+	}
+
+	branches := um.Branches()
+	if len(branches) != 2 {
+		t.Fatalf("expected 2 branches, got %d", len(branches))
+	}
+	if branches[0].Seq != 1 || branches[0].Active || !branches[1].Active {
+		t.Errorf("unexpected branches %+v", branches)
+	}
+
+	pos, ok := um.GoTo(buffer, branches[0].Seq)
+	if !ok {
+		t.Fatalf("GoTo failed")
+	}
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"abc"}) {
+		t.Errorf("expected old branch restored, got %v", got)
+	}
+	if pos.X != 3 || pos.Y != 0 {
+		t.Errorf("expected cursor at (3,0), got (%d,%d)", pos.X, pos.Y)
+	}
+
+	// Redo from the root now follows the branch we visited last.
+	um.Undo(buffer)
+	um.Redo(buffer)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"abc"}) {
+		t.Errorf("expected redo to follow visited branch, got %v", got)
+	}
+}
+
+func TestUndoTree_StepChronologically(t *testing.T) {
+	um := NewUndoManager()
+	fakeClock(um)
+	buffer := makeBufferWithLines([]string{""})
+
+	typeText(um, buffer, 0, 0, "one") // seq 1
+	um.Undo(buffer)                   // back to seq 0
+	typeText(um, buffer, 0, 0, "two") // seq 2
+	um.Boundary()
+	typeText(um, buffer, 0, 3, "!") // seq 3
+
+	want := [][]string{{"two"}, {"one"}, {""}}
+	for i, w := range want {
+		um.StepBack(buffer)
+		if got := getStringLines(buffer); !reflect.DeepEqual(got, w) {
+			t.Errorf("StepBack %d: got %v, want %v", i+1, got, w)
+		}
+	}
+	if _, ok := um.StepBack(buffer); ok {
+		t.Errorf("expected StepBack at the root to fail")
+	}
+
+	for i := 0; i < 3; i++ {
+		um.StepForward(buffer)
+	}
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"two!"}) {
+		t.Errorf("StepForward to newest failed, got %v", got)
+	}
+}
+
+func TestUndoTree_EarlierLater(t *testing.T) {
+	um := NewUndoManager()
+	now := fakeClock(um)
+	buffer := makeBufferWithLines([]string{""})
+
+	*now = now.Add(time.Minute)
+	typeText(um, buffer, 0, 0, "a")
+	*now = now.Add(time.Minute)
+	typeText(um, buffer, 0, 1, "b")
+	*now = now.Add(time.Minute)
+	typeText(um, buffer, 0, 2, "c")
+
+	um.Earlier(buffer, 90*time.Second)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Earlier 90s: got %v", got)
+	}
+
+	um.Earlier(buffer, time.Hour)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("Earlier 1h: got %v", got)
+	}
+
+	um.Later(buffer, time.Minute)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("Later 1m: got %v", got)
+	}
+
+	um.Later(buffer, time.Hour)
+	if got := getStringLines(buffer); !reflect.DeepEqual(got, []string{"abc"}) {
+		t.Errorf("Later 1h: got %v", got)
+	}
+}
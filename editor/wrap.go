@@ -0,0 +1,125 @@
+package editor
+
+import "unicode"
+
+// Wrap describes how buffer lines are folded into display lines.
+type Wrap struct {
+	Width    int // in screen columns
+	TabWidth int
+	// Words breaks after the last space that fits instead of mid-word,
+	// unless a single word is wider than Width.
+	Words bool
+}
+
+// Segment is one display line of a wrapped line: the runes [Start, End).
+type Segment struct {
+	Start int
+	End   int
+}
+
+// Segments splits line into display lines, never inside a character.
+// There is always at least one segment, and a line that exactly fills its
+// last display line gets an empty one after it, so the cursor at the end
+// of the line has a cell.
+func (w Wrap) Segments(line []rune) []Segment {
+	width := max(w.Width, 1)
+	clusters := Layout(line, w.TabWidth)
+	var segments []Segment
+	first := 0 // first cluster of the current segment
+	for i := 0; i < len(clusters); i++ {
+		c := clusters[i]
+		if c.Col+c.Width-clusters[first].Col <= width || i == first {
+			continue
+		}
+		end := i
+		if w.Words {
+			for j := i; j > first; j-- {
+				if unicode.IsSpace(line[clusters[j-1].Start]) {
+					end = j
+					break
+				}
+			}
+		}
+		segments = append(segments, Segment{clusters[first].Start, clusters[end].Start})
+		first, i = end, end-1
+	}
+
+	start, used := len(line), 0
+	if first < len(clusters) {
+		last := clusters[len(clusters)-1]
+		start, used = clusters[first].Start, last.Col+last.Width-clusters[first].Col
+	}
+	segments = append(segments, Segment{start, len(line)})
+	if used >= width {
+		segments = append(segments, Segment{len(line), len(line)})
+	}
+	return segments
+}
+
+// Locate returns which display line of line column x falls on.
+func (w Wrap) Locate(line []rune, x int) (row int, segment Segment) {
+	segments := w.Segments(line)
+	for i, seg := range segments {
+		if x < seg.End || i == len(segments)-1 {
+			return i, seg
+		}
+	}
+	return 0, segments[0] // unreachable, Segments is never empty
+}
+
+// MoveDisplayUp moves to the display line above, which may be part of
+// the same buffer line, keeping the screen column within the display
+// line.
+func (c *CursorPointer) MoveDisplayUp(buffer Buffer, wrap Wrap) {
+	line := buffer.GetLine(c.Y)
+	segments := wrap.Segments(line)
+	row, _ := wrap.Locate(line, c.X)
+	offset := wrap.offset(line, segments[row], c.X)
+	if row > 0 {
+		c.moveToSegment(line, wrap, segments, row-1, offset)
+		return
+	}
+	if c.Y == 0 {
+		return
+	}
+	c.Y--
+	line = buffer.GetLine(c.Y)
+	segments = wrap.Segments(line)
+	c.moveToSegment(line, wrap, segments, len(segments)-1, offset)
+}
+
+// MoveDisplayDown is MoveDisplayUp in the other direction.
+func (c *CursorPointer) MoveDisplayDown(buffer Buffer, wrap Wrap) {
+	line := buffer.GetLine(c.Y)
+	segments := wrap.Segments(line)
+	row, _ := wrap.Locate(line, c.X)
+	offset := wrap.offset(line, segments[row], c.X)
+	if row < len(segments)-1 {
+		c.moveToSegment(line, wrap, segments, row+1, offset)
+		return
+	}
+	if c.Y >= buffer.LineCount()-1 {
+		return
+	}
+	c.Y++
+	line = buffer.GetLine(c.Y)
+	c.moveToSegment(line, wrap, wrap.Segments(line), 0, offset)
+}
+
+// offset returns the screen columns between the start of seg and x.
+func (w Wrap) offset(line []rune, seg Segment, x int) int {
+	return DisplayColumn(line, x, w.TabWidth) - DisplayColumn(line, seg.Start, w.TabWidth)
+}
+
+// moveToSegment puts the cursor offset columns into segments[row],
+// staying on that display line: only the last one may hold the
+// end-of-line position.
+func (c *CursorPointer) moveToSegment(line []rune, wrap Wrap, segments []Segment, row, offset int) {
+	seg := segments[row]
+	last := seg.End
+	if row < len(segments)-1 {
+		last = max(prevCluster(line, seg.End), seg.Start)
+	}
+	x := RuneAt(line, DisplayColumn(line, seg.Start, wrap.TabWidth)+offset, wrap.TabWidth)
+	c.X = min(x, last)
+}
@@ -0,0 +1,95 @@
+package editor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func segmentText(line string, segments []Segment) []string {
+	runes := []rune(line)
+	var out []string
+	for _, seg := range segments {
+		out = append(out, string(runes[seg.Start:seg.End]))
+	}
+	return out
+}
+
+func TestWrap_Segments(t *testing.T) {
+	tests := []struct {
+		line string
+		wrap Wrap
+		want []string
+	}{
+		{"", Wrap{Width: 4}, []string{""}},
+		{"abc", Wrap{Width: 4}, []string{"abc"}},
+		{"abcdefghij", Wrap{Width: 4}, []string{"abcd", "efgh", "ij"}},
+		{"abcdefgh", Wrap{Width: 4}, []string{"abcd", "efgh", ""}},
+		{"the quick brown fox", Wrap{Width: 10, Words: true}, []string{"the quick ", "brown fox"}},
+		{"the quick brown fox", Wrap{Width: 10}, []string{"the quick ", "brown fox"}},
+		{"a verylongword here", Wrap{Width: 6, Words: true}, []string{"a ", "verylo", "ngword", " here"}},
+	}
+	for _, tt := range tests {
+		got := segmentText(tt.line, tt.wrap.Segments([]rune(tt.line)))
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Segments(%q, %+v) = %q, want %q", tt.line, tt.wrap, got, tt.want)
+		}
+	}
+}
+
+func TestWrap_Locate(t *testing.T) {
+	wrap := Wrap{Width: 4}
+	line := []rune("abcdefghij")
+	for x, want := range map[int]int{0: 0, 3: 0, 4: 1, 7: 1, 8: 2, 10: 2} {
+		if row, _ := wrap.Locate(line, x); row != want {
+			t.Errorf("Locate(%d) = row %d, want %d", x, row, want)
+		}
+	}
+}
+
+func TestCursor_MoveDisplayDownAndUp(t *testing.T) {
+	buf := &mockBuffer{lines: []string{"abcdefghij", "xy", "0123456789"}}
+	wrap := Wrap{Width: 4}
+	cursor := NewCursor(1, 0)
+
+	steps := []struct{ x, y int }{
+		{5, 0}, // next display line of the same buffer line
+		{9, 0},
+		{1, 1}, // onto the next buffer line, same offset
+		{1, 2},
+		{5, 2},
+	}
+	for i, want := range steps {
+		cursor.MoveDisplayDown(buf, wrap)
+		if x, y := cursor.GetPosition(); x != want.x || y != want.y {
+			t.Fatalf("down %d: expected (%d,%d), got (%d,%d)", i+1, want.x, want.y, x, y)
+		}
+	}
+	for i := len(steps) - 2; i >= 0; i-- {
+		cursor.MoveDisplayUp(buf, wrap)
+		want := steps[i]
+		if x, y := cursor.GetPosition(); x != want.x || y != want.y {
+			t.Fatalf("up to step %d: expected (%d,%d), got (%d,%d)", i+1, want.x, want.y, x, y)
+		}
+	}
+	cursor.MoveDisplayUp(buf, wrap)
+	if x, y := cursor.GetPosition(); x != 1 || y != 0 {
+		t.Errorf("expected (1,0), got (%d,%d)", x, y)
+	}
+}
+
+func TestCursor_MoveDisplayKeepsToDisplayLine(t *testing.T) {
+	buf := &mockBuffer{lines: []string{"ab", "abcdefgh"}}
+	wrap := Wrap{Width: 4}
+	cursor := NewCursor(3, 1) // end of the first display line "abcd"
+
+	cursor.MoveDisplayUp(buf, wrap)
+	if x, y := cursor.GetPosition(); x != 2 || y != 0 {
+		t.Errorf("expected clamp to (2,0), got (%d,%d)", x, y)
+	}
+	cursor.SetPosition(2, 0, buf)
+	cursor.MoveDisplayDown(buf, wrap)
+	cursor.MoveDisplayDown(buf, wrap)
+	if x, y := cursor.GetPosition(); x != 6 || y != 1 {
+		t.Errorf("expected (6,1), got (%d,%d)", x, y)
+	}
+}
@@ -18,13 +18,7 @@ func init() {
 }
 
 func main() {
-	var model app.Model
-	if len(os.Args) > 1 {
-		model = app.NewModel(os.Args[1])
-	} else {
-		model = app.NewModel("")
-	}
-	p := tea.NewProgram(model)
+	p := tea.NewProgram(app.NewModel(os.Args[1:]...), tea.WithReportFocus())
 	if err := p.Start(); err != nil {
 		panic(err)
 	}
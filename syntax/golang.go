@@ -0,0 +1,123 @@
+package syntax
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// Go states: between tokens, inside a /* comment or inside a `raw string`.
+const (
+	goCode State = iota
+	goComment
+	goRawString
+)
+
+// Go highlights Go source with go/scanner, one line at a time.
+type Go struct{}
+
+var goTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true,
+	"complex64": true, "complex128": true, "error": true,
+	"float32": true, "float64": true, "int": true, "int8": true,
+	"int16": true, "int32": true, "int64": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true,
+	"uint32": true, "uint64": true, "uintptr": true,
+}
+
+// goPunctuation are the tokens go/scanner counts as operators that are
+// left plain.
+var goPunctuation = map[token.Token]bool{
+	token.LPAREN: true, token.RPAREN: true, token.LBRACE: true,
+	token.RBRACE: true, token.LBRACK: true, token.RBRACK: true,
+	token.COMMA: true, token.PERIOD: true, token.SEMICOLON: true,
+	token.COLON: true,
+}
+
+var goConstants = map[string]bool{
+	"true": true, "false": true, "nil": true, "iota": true,
+}
+
+func (Go) Lex(line []rune, state State) ([]Token, State) {
+	src := string(line)
+	var tokens []Token
+	offset := 0 // bytes of src already covered
+
+	// finish a comment or raw string left open by an earlier line
+	if state != goCode {
+		closer, class := "*/", Comment
+		if state == goRawString {
+			closer, class = "`", String
+		}
+		i := strings.Index(src, closer)
+		if i < 0 {
+			return []Token{{0, len(line), class}}, state
+		}
+		offset = i + len(closer)
+		tokens = append(tokens, Token{0, utf8.RuneCountInString(src[:offset]), class})
+	}
+
+	runeAt := func(b int) int { return utf8.RuneCountInString(src[:b]) }
+	fset := token.NewFileSet()
+	rest := src[offset:]
+	file := fset.AddFile("", fset.Base(), len(rest))
+	var s scanner.Scanner
+	s.Init(file, []byte(rest), func(token.Position, string) {}, scanner.ScanComments)
+
+	state = goCode
+	prev := -1 // index of a plain identifier just seen, a Function if ( follows
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue // inserted by the scanner, not in the source
+		}
+		start := offset + file.Offset(pos)
+		end := start + len(tok.String())
+		if lit != "" {
+			end = start + len(lit)
+		}
+		end = min(end, len(src))
+
+		class := Plain
+		switch {
+		case tok == token.COMMENT:
+			class = Comment
+			if strings.HasPrefix(lit, "/*") && (len(lit) < 4 || !strings.HasSuffix(lit, "*/")) {
+				state = goComment
+			}
+		case tok == token.STRING:
+			class = String
+			if strings.HasPrefix(lit, "`") && (len(lit) < 2 || !strings.HasSuffix(lit, "`")) {
+				state = goRawString
+			}
+		case tok == token.CHAR:
+			class = String
+		case tok == token.INT, tok == token.FLOAT, tok == token.IMAG:
+			class = Number
+		case tok.IsKeyword():
+			class = Keyword
+		case tok == token.IDENT && goTypes[lit]:
+			class = Type
+		case tok == token.IDENT && goConstants[lit]:
+			class = Constant
+		case tok == token.LPAREN && prev >= 0:
+			tokens[prev].Class = Function
+		case tok.IsOperator() && !goPunctuation[tok]:
+			class = Operator
+		}
+
+		prev = -1
+		if class == Plain && tok != token.IDENT {
+			continue
+		}
+		tokens = append(tokens, Token{runeAt(start), runeAt(end), class})
+		if tok == token.IDENT && class == Plain {
+			prev = len(tokens) - 1
+		}
+	}
+	return tokens, state
+}
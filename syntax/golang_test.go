@@ -0,0 +1,75 @@
+package syntax
+
+import (
+	"fmt"
+	"testing"
+)
+
+// classesOf describes tokens as "text:class" for readable comparisons.
+func classesOf(line string, tokens []Token) []string {
+	runes := []rune(line)
+	var out []string
+	for _, tok := range tokens {
+		if tok.Class != Plain {
+			out = append(out, string(runes[tok.Start:tok.End])+":"+tok.Class.String())
+		}
+	}
+	return out
+}
+
+func TestGo_Tokens(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`func main() {`, []string{"func:keyword", "main:function"}},
+		{`	x := 42 // answer`, []string{":=:operator", "42:number", "// answer:comment"}},
+		{`var s string = "héllo"`, []string{"var:keyword", "string:type", "=:operator", `"héllo":string`}},
+		{`if err != nil { return }`, []string{"if:keyword", "!=:operator", "nil:constant", "return:keyword"}},
+		{`fmt.Println('x', 1.5)`, []string{"Println:function", "'x':string", "1.5:number"}},
+		{`n := int(x)`, []string{":=:operator", "int:type"}},
+	}
+	for _, tt := range tests {
+		tokens, state := Go{}.Lex([]rune(tt.line), 0)
+		if got := classesOf(tt.line, tokens); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Lex(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+		if state != goCode {
+			t.Errorf("Lex(%q) left state %v", tt.line, state)
+		}
+	}
+}
+
+func TestGo_MultiLineComment(t *testing.T) {
+	lines := []string{"x := 1 /* start", "still comment", "end */ y := 2"}
+	want := [][]string{
+		{":=:operator", "1:number", "/* start:comment"},
+		{"still comment:comment"},
+		{"end */:comment", ":=:operator", "2:number"},
+	}
+	state := State(0)
+	for i, line := range lines {
+		var tokens []Token
+		tokens, state = Go{}.Lex([]rune(line), state)
+		if got := classesOf(line, tokens); fmt.Sprint(got) != fmt.Sprint(want[i]) {
+			t.Errorf("line %d: got %q, want %q", i, got, want[i])
+		}
+	}
+	if state != goCode {
+		t.Errorf("expected the comment closed, state %v", state)
+	}
+}
+
+func TestGo_RawString(t *testing.T) {
+	tokens, state := Go{}.Lex([]rune("s := `first"), 0)
+	if state != goRawString {
+		t.Fatalf("expected to stay in the raw string, state %v", state)
+	}
+	tokens, state = Go{}.Lex([]rune("second` + x"), state)
+	if got := classesOf("second` + x", tokens); fmt.Sprint(got) != fmt.Sprint([]string{"second`:string", "+:operator"}) {
+		t.Errorf("got %q", got)
+	}
+	if state != goCode {
+		t.Errorf("expected the raw string closed, state %v", state)
+	}
+}
@@ -0,0 +1,166 @@
+package syntax
+
+import "path/filepath"
+
+// Class is the kind of a token; the renderer picks a style per class.
+type Class int
+
+const (
+	Plain Class = iota
+	Keyword
+	Type
+	Function
+	Constant
+	String
+	Number
+	Comment
+	Operator
+	Heading
+	Emphasis
+	Strong
+	Code
+	Link
+	Quote
+	ListMarker
+)
+
+var classNames = []string{
+	"plain", "keyword", "type", "function", "constant", "string", "number",
+	"comment", "operator", "heading", "emphasis", "strong", "code", "link",
+	"quote", "listMarker",
+}
+
+func (c Class) String() string {
+	if c < 0 || int(c) >= len(classNames) {
+		return "plain"
+	}
+	return classNames[c]
+}
+
+// Classes lists every token class, e.g. for themes to style them all.
+func Classes() []Class {
+	classes := make([]Class, len(classNames))
+	for i := range classes {
+		classes[i] = Class(i)
+	}
+	return classes
+}
+
+// Token marks the runes [Start, End) of a line as Class. Runes not
+// covered by any token are Plain.
+type Token struct {
+	Start int
+	End   int
+	Class Class
+}
+
+// State is what a lexer carries from one line to the next, such as being
+// inside a block comment. The zero State is the start of a file.
+type State int
+
+// Lexer tokenizes one line at a time, given the state at its start.
+type Lexer interface {
+	Lex(line []rune, state State) ([]Token, State)
+}
+
+// ForFile picks a lexer by the extension of path, or nil if there is no
+// highlighting for it.
+func ForFile(path string) Lexer {
+	switch filepath.Ext(path) {
+	case ".go":
+		return Go{}
+	case ".md", ".markdown":
+		return Markdown{}
+	}
+	return nil
+}
+
+// Lines is the text a Highlighter reads, one line at a time, so that only
+// the lines it lexes are fetched.
+type Lines interface {
+	GetLine(y int) []rune
+	LineCount() int
+}
+
+type lineCache struct {
+	start  State // state at the start of the line
+	end    State
+	tokens []Token
+	stale  bool // the line changed since it was lexed
+}
+
+// Highlighter caches the tokens and lexer state of every line, so after
+// an edit only the changed lines, and those after them whose start state
+// changed as a result, are lexed again. Lines are lexed lazily, up to the
+// last one asked for. A nil Highlighter highlights nothing.
+type Highlighter struct {
+	lexer Lexer
+	cache []lineCache
+	valid int // cache[:valid] is up to date
+}
+
+func NewHighlighter(lexer Lexer) *Highlighter {
+	if lexer == nil {
+		return nil
+	}
+	return &Highlighter{lexer: lexer}
+}
+
+// Invalidate records an edit to line that also inserted delta lines after
+// it, or removed -delta lines when delta is negative.
+func (h *Highlighter) Invalidate(line, delta int) {
+	if h == nil || line >= len(h.cache) {
+		return
+	}
+	line = max(line, 0)
+	h.cache[line].stale = true
+	switch {
+	case delta > 0:
+		added := make([]lineCache, delta)
+		for i := range added {
+			added[i].stale = true
+		}
+		h.cache = append(h.cache[:line+1], append(added, h.cache[line+1:]...)...)
+	case delta < 0:
+		end := min(line+1-delta, len(h.cache))
+		h.cache = append(h.cache[:line+1], h.cache[end:]...)
+	}
+	h.valid = min(h.valid, line)
+}
+
+// Reset forgets everything, for when the whole buffer may have changed.
+func (h *Highlighter) Reset() {
+	if h == nil {
+		return
+	}
+	h.cache = nil
+	h.valid = 0
+}
+
+// Tokens returns the tokens of line y.
+func (h *Highlighter) Tokens(lines Lines, y int) []Token {
+	if h == nil || y < 0 || y >= lines.LineCount() {
+		return nil
+	}
+	if len(h.cache) != lines.LineCount() { // missed an edit; start over
+		h.cache = make([]lineCache, lines.LineCount())
+		h.valid = 0
+	}
+	state := State(0)
+	if h.valid > 0 {
+		state = h.cache[h.valid-1].end
+	}
+	for ; h.valid <= y; h.valid++ {
+		c := &h.cache[h.valid]
+		// a line is reused if neither it nor the state it starts in changed
+		if c.stale || c.start != state || c.tokens == nil {
+			c.tokens, c.end = h.lexer.Lex(lines.GetLine(h.valid), state)
+			if c.tokens == nil {
+				c.tokens = []Token{}
+			}
+			c.start, c.stale = state, false
+		}
+		state = c.end
+	}
+	return h.cache[y].tokens
+}
@@ -0,0 +1,144 @@
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+// countingLexer marks lines starting with "{" as opening a block that the
+// next "}" line closes, and records which lines it lexed.
+type countingLexer struct {
+	lexed []string
+}
+
+func (l *countingLexer) Lex(line []rune, state State) ([]Token, State) {
+	l.lexed = append(l.lexed, string(line))
+	switch {
+	case strings.HasPrefix(string(line), "{"):
+		state = 1
+	case strings.HasPrefix(string(line), "}"):
+		state = 0
+	}
+	return []Token{{0, len(line), Class(state)}}, state
+}
+
+// lineSlice gives a plain slice of lines the Lines methods.
+type lineSlice [][]rune
+
+func (l lineSlice) GetLine(y int) []rune { return l[y] }
+func (l lineSlice) LineCount() int       { return len(l) }
+
+func toLines(text ...string) lineSlice {
+	lines := make(lineSlice, len(text))
+	for i, line := range text {
+		lines[i] = []rune(line)
+	}
+	return lines
+}
+
+func TestHighlighter_LexesLazily(t *testing.T) {
+	lexer := &countingLexer{}
+	h := NewHighlighter(lexer)
+	lines := toLines("a", "b", "c", "d")
+
+	h.Tokens(lines, 1)
+	if len(lexer.lexed) != 2 {
+		t.Errorf("expected lines 0-1 lexed, got %q", lexer.lexed)
+	}
+	h.Tokens(lines, 0)
+	h.Tokens(lines, 1)
+	if len(lexer.lexed) != 2 {
+		t.Errorf("expected cached tokens reused, got %q", lexer.lexed)
+	}
+}
+
+func TestHighlighter_RelexesOnlyEditedLine(t *testing.T) {
+	lexer := &countingLexer{}
+	h := NewHighlighter(lexer)
+	lines := toLines("a", "b", "c", "d")
+	h.Tokens(lines, 3)
+
+	lexer.lexed = nil
+	lines[1] = []rune("B")
+	h.Invalidate(1, 0)
+	h.Tokens(lines, 3)
+	if strings.Join(lexer.lexed, ",") != "B" {
+		t.Errorf("expected only the edited line lexed, got %q", lexer.lexed)
+	}
+}
+
+func TestHighlighter_StateChangePropagates(t *testing.T) {
+	lexer := &countingLexer{}
+	h := NewHighlighter(lexer)
+	lines := toLines("a", "b", "c", "}", "d")
+	h.Tokens(lines, 4)
+
+	lexer.lexed = nil
+	lines[0] = []rune("{")
+	h.Invalidate(0, 0)
+	if got := h.Tokens(lines, 2); got[0].Class != 1 {
+		t.Errorf("expected line 2 inside the block, got %v", got)
+	}
+	if got := h.Tokens(lines, 4); got[0].Class != 0 {
+		t.Errorf("expected line 4 after the block, got %v", got)
+	}
+	// line 4 starts in the same state as before, so it isn't lexed again
+	if strings.Join(lexer.lexed, ",") != "{,b,c,}" {
+		t.Errorf("unexpected lines lexed: %q", lexer.lexed)
+	}
+}
+
+func TestHighlighter_InsertAndRemoveLines(t *testing.T) {
+	lexer := &countingLexer{}
+	h := NewHighlighter(lexer)
+	lines := toLines("a", "b", "c")
+	h.Tokens(lines, 2)
+
+	lexer.lexed = nil
+	lines = toLines("a", "x", "y", "b", "c")
+	h.Invalidate(0, 2) // "x" and "y" typed on new lines after "a"
+	h.Tokens(lines, 4)
+	if strings.Join(lexer.lexed, ",") != "a,x,y" {
+		t.Errorf("expected only the new lines lexed, got %q", lexer.lexed)
+	}
+
+	lexer.lexed = nil
+	lines = toLines("ab", "c")
+	h.Invalidate(0, -3)
+	h.Tokens(lines, 1)
+	if strings.Join(lexer.lexed, ",") != "ab" {
+		t.Errorf("expected only the merged line lexed, got %q", lexer.lexed)
+	}
+}
+
+func TestHighlighter_RecoversFromMissedEdit(t *testing.T) {
+	h := NewHighlighter(&countingLexer{})
+	h.Tokens(toLines("a", "b"), 1)
+	if got := h.Tokens(toLines("a", "b", "{"), 2); got[0].Class != 1 {
+		t.Errorf("expected the new line lexed, got %v", got)
+	}
+}
+
+func TestHighlighter_Nil(t *testing.T) {
+	h := NewHighlighter(nil)
+	if h != nil {
+		t.Fatalf("expected no highlighter without a lexer")
+	}
+	h.Invalidate(0, 1)
+	h.Reset()
+	if h.Tokens(toLines("a"), 0) != nil {
+		t.Errorf("expected no tokens")
+	}
+}
+
+func TestForFile(t *testing.T) {
+	if _, ok := ForFile("/src/main.go").(Go); !ok {
+		t.Errorf("expected Go for .go files")
+	}
+	if _, ok := ForFile("README.md").(Markdown); !ok {
+		t.Errorf("expected Markdown for .md files")
+	}
+	if ForFile("notes.txt") != nil || ForFile("") != nil {
+		t.Errorf("expected no lexer for plain text")
+	}
+}
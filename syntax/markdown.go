@@ -0,0 +1,127 @@
+package syntax
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Markdown states: in running text, or inside a ``` or ~~~ fenced block.
+const (
+	mdText State = iota
+	mdFenceTicks
+	mdFenceTildes
+)
+
+// Markdown highlights headings, quotes, list markers, fenced code and the
+// inline code, emphasis and links of CommonMark, without nesting.
+type Markdown struct{}
+
+func (Markdown) Lex(line []rune, state State) ([]Token, State) {
+	trimmed := strings.TrimLeft(string(line), " ")
+	indent := len(line) - len([]rune(trimmed))
+	whole := []Token{{0, len(line), Code}}
+
+	switch state {
+	case mdFenceTicks, mdFenceTildes:
+		if fenceState(trimmed) == state {
+			return whole, mdText
+		}
+		return whole, state
+	}
+	if fence := fenceState(trimmed); fence != mdText {
+		return whole, fence
+	}
+	if isHeading(trimmed) {
+		return []Token{{0, len(line), Heading}}, mdText
+	}
+	if strings.HasPrefix(trimmed, ">") {
+		return []Token{{0, len(line), Quote}}, mdText
+	}
+
+	var tokens []Token
+	if n := listMarker(trimmed); n > 0 {
+		tokens = append(tokens, Token{indent, indent + n, ListMarker})
+		indent += n
+	}
+	return append(tokens, lexInline(line, indent)...), mdText
+}
+
+func fenceState(trimmed string) State {
+	switch {
+	case strings.HasPrefix(trimmed, "```"):
+		return mdFenceTicks
+	case strings.HasPrefix(trimmed, "~~~"):
+		return mdFenceTildes
+	}
+	return mdText
+}
+
+// isHeading matches an ATX heading: one to six #s, then a space or the
+// end of the line.
+func isHeading(trimmed string) bool {
+	level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	return level >= 1 && level <= 6 && (len(trimmed) == level || trimmed[level] == ' ')
+}
+
+// listMarker returns the length of a "- ", "* ", "+ " or "12. " marker
+// starting trimmed, including its space, or 0.
+func listMarker(trimmed string) int {
+	if len(trimmed) >= 2 && strings.ContainsRune("-*+", rune(trimmed[0])) && trimmed[1] == ' ' {
+		return 2
+	}
+	digits := len(trimmed) - len(strings.TrimLeft(trimmed, "0123456789"))
+	if digits > 0 && len(trimmed) > digits+1 &&
+		(trimmed[digits] == '.' || trimmed[digits] == ')') && trimmed[digits+1] == ' ' {
+		return digits + 2
+	}
+	return 0
+}
+
+// lexInline finds code spans, strong and emphasised text and links in
+// line from rune from onward.
+func lexInline(line []rune, from int) []Token {
+	var tokens []Token
+	for i := from; i < len(line); i++ {
+		r := line[i]
+		end := -1
+		class := Plain
+		switch {
+		case r == '\\':
+			i++ // escaped character
+			continue
+		case r == '`':
+			end, class = closing(line, i+1, "`"), Code
+		case (r == '*' || r == '_') && i+1 < len(line) && line[i+1] == r:
+			end, class = closing(line, i+2, string([]rune{r, r})), Strong
+		case r == '*' || (r == '_' && (i == 0 || !isWordRune(line[i-1]))):
+			end, class = closing(line, i+1, string(r)), Emphasis
+		case r == '[':
+			if close := closing(line, i+1, "]("); close > 0 {
+				end, class = closing(line, close, ")"), Link
+			}
+		}
+		if end <= i+1 || class == Plain {
+			continue
+		}
+		tokens = append(tokens, Token{i, end, class})
+		i = end - 1
+	}
+	return tokens
+}
+
+// closing returns the rune index just past the first delim in line at or
+// after from, or -1.
+func closing(line []rune, from int, delim string) int {
+	if from > len(line) {
+		return -1
+	}
+	i := strings.Index(string(line[from:]), delim)
+	if i < 0 {
+		return -1
+	}
+	return from + len([]rune(string(line[from:])[:i])) + len([]rune(delim))
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
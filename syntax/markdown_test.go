@@ -0,0 +1,48 @@
+package syntax
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMarkdown_Blocks(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"# Title", []string{"# Title:heading"}},
+		{"###### Deep", []string{"###### Deep:heading"}},
+		{"#hashtag", nil},
+		{"> quoted", []string{"> quoted:quote"}},
+		{"- item", []string{"- :listMarker"}},
+		{"  12. item `code`", []string{"12. :listMarker", "`code`:code"}},
+	}
+	for _, tt := range tests {
+		tokens, _ := Markdown{}.Lex([]rune(tt.line), 0)
+		if got := classesOf(tt.line, tokens); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Lex(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdown_Inline(t *testing.T) {
+	line := "Use **bold**, *em*, `x*y` and [the docs](https://go.dev) in snake_case_name."
+	tokens, _ := Markdown{}.Lex([]rune(line), 0)
+	want := []string{"**bold**:strong", "*em*:emphasis", "`x*y`:code", "[the docs](https://go.dev):link"}
+	if got := classesOf(line, tokens); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMarkdown_FencedCode(t *testing.T) {
+	lines := []string{"```go", "# not a heading", "~~~", "```", "# heading"}
+	want := []string{"code", "code", "code", "code", "heading"}
+	state := State(0)
+	for i, line := range lines {
+		var tokens []Token
+		tokens, state = Markdown{}.Lex([]rune(line), state)
+		if len(tokens) != 1 || tokens[0].Class.String() != want[i] {
+			t.Errorf("line %d %q: got %v, want %s", i, line, tokens, want[i])
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+)
+
+// Dialog is a modal box with a message and a row of buttons, one of them
+// selected.
+type Dialog struct {
+	Title    string
+	Lines    []string
+	Buttons  []string
+	Selected int
+}
+
+// RenderDialog draws d in the middle of a width by height area. Lines
+// that don't fit are cut short, and when there are too many the rest are
+// counted instead.
+func RenderDialog(d Dialog, width, height int) string {
+	// the border and padding take 4 columns and 2 rows; the title,
+	// buttons and the blank lines around them take 4 rows more
+	inner := max(width-4, 1)
+	room := max(height-6, 1)
+	lines := d.Lines
+	if len(lines) > room {
+		more := len(lines) - room + 1
+		lines = append(lines[:room-1:room-1], fmt.Sprintf("… and %d more", more))
+	}
+
+	rows := []string{dialogTitleStyle.Render(ansi.Truncate(d.Title, inner, "…")), ""}
+	for _, line := range lines {
+		rows = append(rows, ansi.Truncate(line, inner, "…"))
+	}
+	buttons := make([]string, len(d.Buttons))
+	for i, label := range d.Buttons {
+		style := dialogButtonStyle
+		if i == d.Selected {
+			style = dialogSelectedStyle
+		}
+		buttons[i] = style.Render(label)
+	}
+	rows = append(rows, "", ansi.Truncate(strings.Join(buttons, " "), inner, ""))
+
+	box := dialogStyle.Render(strings.Join(rows, "\n"))
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
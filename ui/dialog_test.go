@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+	"testing"
+)
+
+func TestRenderDialog_FillsArea(t *testing.T) {
+	d := Dialog{
+		Title:   "Unsaved changes",
+		Lines:   []string{"a.txt"},
+		Buttons: []string{"Save", "Discard", "Cancel"},
+	}
+	out := RenderDialog(d, 50, 12)
+	if w, h := lipgloss.Size(out); w != 50 || h != 12 {
+		t.Errorf("expected 50x12, got %dx%d", w, h)
+	}
+	plain := ansi.Strip(out)
+	for _, want := range []string{"Unsaved changes", "a.txt", "Save", "Discard", "Cancel"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("expected %q in dialog:\n%s", want, plain)
+		}
+	}
+}
+
+func TestRenderDialog_HighlightsSelected(t *testing.T) {
+	d := Dialog{Title: "Quit?", Buttons: []string{"Yes", "No"}, Selected: 1}
+	out := RenderDialog(d, 40, 10)
+	if !strings.Contains(out, dialogSelectedStyle.Render("No")) {
+		t.Errorf("expected No selected in %q", out)
+	}
+	if strings.Contains(out, dialogSelectedStyle.Render("Yes")) {
+		t.Errorf("expected Yes not selected in %q", out)
+	}
+}
+
+func TestRenderDialog_CountsLinesThatDontFit(t *testing.T) {
+	var lines []string
+	for i := range 20 {
+		lines = append(lines, fmt.Sprintf("file%d.go", i))
+	}
+	out := ansi.Strip(RenderDialog(Dialog{Title: "Unsaved", Lines: lines, Buttons: []string{"OK"}}, 40, 12))
+	if _, h := lipgloss.Size(out); h != 12 {
+		t.Errorf("expected height 12, got %d:\n%s", h, out)
+	}
+	if !strings.Contains(out, "file4.go") || strings.Contains(out, "file5.go") || !strings.Contains(out, "and 15 more") {
+		t.Errorf("expected five files and a count of the rest:\n%s", out)
+	}
+}
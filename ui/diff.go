@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"editGo/editor"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+)
+
+// diffContext is how many unchanged lines are shown around each change.
+const diffContext = 2
+
+// RenderDiff shows the changed lines of diff with a little context,
+// eliding long unchanged runs, in a width by height block.
+func RenderDiff(title string, diff []editor.DiffLine, width, height int) string {
+	keep := make([]bool, len(diff))
+	for i, d := range diff {
+		if d.Kind == editor.DiffEqual {
+			continue
+		}
+		for j := max(0, i-diffContext); j <= min(len(diff)-1, i+diffContext); j++ {
+			keep[j] = true
+		}
+	}
+
+	cut := func(text string) string { return ansi.Truncate(text, width, "…") }
+	rows := []string{cut(title)}
+	skipped := false
+	for i, d := range diff {
+		if !keep[i] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			rows = append(rows, diffContextStyle.Render("⋯"))
+			skipped = false
+		}
+		switch d.Kind {
+		case editor.DiffInsert:
+			rows = append(rows, diffInsertStyle.Render(cut("+ "+d.Text)))
+		case editor.DiffDelete:
+			rows = append(rows, diffDeleteStyle.Render(cut("- "+d.Text)))
+		default:
+			rows = append(rows, cut("  "+d.Text))
+		}
+	}
+	if len(rows) == 1 {
+		rows = append(rows, diffContextStyle.Render("(no differences)"))
+	}
+	if len(rows) > height {
+		rows = append(rows[:max(height-1, 0)], diffContextStyle.Render(cut("⋯ more changes not shown")))
+	}
+	for len(rows) < height {
+		rows = append(rows, "")
+	}
+	return strings.Join(rows, "\n")
+}
+
+// RenderPrompt shows a question the user has to answer before editing on.
+func RenderPrompt(msg string, width int) string {
+	return fit(promptStyle, msg, width)
+}
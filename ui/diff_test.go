@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"editGo/editor"
+	"github.com/charmbracelet/lipgloss"
+	"strings"
+	"testing"
+)
+
+func TestRenderDiff_FitsBlock(t *testing.T) {
+	var diff []editor.DiffLine
+	for i := 0; i < 50; i++ {
+		diff = append(diff, editor.DiffLine{Kind: editor.DiffInsert, Text: strings.Repeat("x", 100)})
+	}
+	out := RenderDiff("title", diff, 30, 10)
+	rows := strings.Split(out, "\n")
+	if len(rows) != 10 {
+		t.Fatalf("expected 10 rows, got %d", len(rows))
+	}
+	for i, row := range rows {
+		if lipgloss.Width(row) > 30 {
+			t.Errorf("row %d wider than 30: %q", i, row)
+		}
+	}
+	if !strings.Contains(rows[9], "more changes") {
+		t.Errorf("expected the last row to note hidden changes, got %q", rows[9])
+	}
+}
+
+func TestRenderDiff_PadsToHeight(t *testing.T) {
+	out := RenderDiff("title", nil, 30, 5)
+	if rows := strings.Count(out, "\n") + 1; rows != 5 {
+		t.Errorf("expected 5 rows, got %d", rows)
+	}
+}
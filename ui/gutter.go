@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"strconv"
+	"strings"
+)
+
+type LineNumbers int
+
+const (
+	NumbersOff LineNumbers = iota
+	NumbersAbsolute
+	NumbersRelative
+	// NumbersHybrid shows the cursor line's number and relative numbers
+	// on every other line.
+	NumbersHybrid
+)
+
+var lineNumberNames = []string{"off", "absolute", "relative", "hybrid"}
+
+func (n LineNumbers) String() string {
+	if n < 0 || int(n) >= len(lineNumberNames) {
+		return "off"
+	}
+	return lineNumberNames[n]
+}
+
+// Next returns the mode after n, wrapping around, for toggling through
+// them with one key.
+func (n LineNumbers) Next() LineNumbers {
+	return (n + 1) % LineNumbers(len(lineNumberNames))
+}
+
+func ParseLineNumbers(name string) (LineNumbers, error) {
+	for i, known := range lineNumberNames {
+		if strings.EqualFold(name, known) {
+			return LineNumbers(i), nil
+		}
+	}
+	return NumbersOff, fmt.Errorf("unknown line number mode %q", name)
+}
+
+// Sign is a mark drawn in the gutter beside a line, such as a diagnostic
+// or a changed-line marker. Text should be at most signWidth cells wide.
+type Sign struct {
+	Text  string
+	Style lipgloss.Style
+}
+
+// SignSource supplies the signs for one feature.
+type SignSource interface {
+	Sign(line int) (Sign, bool)
+}
+
+// SignFunc adapts a function to a SignSource.
+type SignFunc func(line int) (Sign, bool)
+
+func (f SignFunc) Sign(line int) (Sign, bool) {
+	return f(line)
+}
+
+const signWidth = 2
+
+// Gutter is the column left of the text with line numbers and, when any
+// sign sources are set, a sign column. Earlier sources win when several
+// have a sign for the same line.
+type Gutter struct {
+	Numbers LineNumbers
+	Signs   []SignSource
+}
+
+// Width returns how many cells the gutter takes for a buffer of
+// lineCount lines.
+func (g Gutter) Width(lineCount int) int {
+	width := 0
+	if len(g.Signs) > 0 {
+		width += signWidth
+	}
+	if g.Numbers != NumbersOff {
+		width += g.numberWidth(lineCount) + 1
+	}
+	return width
+}
+
+func (g Gutter) numberWidth(lineCount int) int {
+	return max(len(strconv.Itoa(lineCount)), 2)
+}
+
+// Render draws the gutter for line y; lines past the end of the buffer
+// get a blank gutter.
+func (g Gutter) Render(y, cursorY, lineCount int) string {
+	var out strings.Builder
+	if len(g.Signs) > 0 {
+		out.WriteString(g.renderSign(y, lineCount))
+	}
+	if g.Numbers == NumbersOff {
+		return out.String()
+	}
+
+	width := g.numberWidth(lineCount)
+	if y >= lineCount {
+		out.WriteString(strings.Repeat(" ", width+1))
+		return out.String()
+	}
+	number := y + 1
+	distance := max(y-cursorY, cursorY-y)
+	switch {
+	case g.Numbers == NumbersRelative:
+		number = distance
+	case g.Numbers == NumbersHybrid && y != cursorY:
+		number = distance
+	}
+	style := gutterStyle
+	if y == cursorY {
+		style = gutterCurrentStyle
+	}
+	out.WriteString(style.Render(fmt.Sprintf("%*d", width, number)) + " ")
+	return out.String()
+}
+
+// Blank is an empty gutter, for the continuation rows of wrapped lines.
+func (g Gutter) Blank(lineCount int) string {
+	return strings.Repeat(" ", g.Width(lineCount))
+}
+
+func (g Gutter) renderSign(y, lineCount int) string {
+	if y < lineCount {
+		for _, source := range g.Signs {
+			if sign, ok := source.Sign(y); ok {
+				return sign.Style.Width(signWidth).MaxWidth(signWidth).Render(sign.Text)
+			}
+		}
+	}
+	return strings.Repeat(" ", signWidth)
+}
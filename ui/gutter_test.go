@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+	"testing"
+)
+
+func gutterColumn(g Gutter, cursorY, lineCount, rows int) []string {
+	var out []string
+	for y := 0; y < rows; y++ {
+		out = append(out, ansi.Strip(g.Render(y, cursorY, lineCount)))
+	}
+	return out
+}
+
+func TestGutter_Modes(t *testing.T) {
+	tests := []struct {
+		mode LineNumbers
+		want []string
+	}{
+		{NumbersAbsolute, []string{" 1 ", " 2 ", " 3 ", " 4 "}},
+		{NumbersRelative, []string{" 2 ", " 1 ", " 0 ", " 1 "}},
+		{NumbersHybrid, []string{" 2 ", " 1 ", " 3 ", " 1 "}},
+	}
+	for _, tt := range tests {
+		got := gutterColumn(Gutter{Numbers: tt.mode}, 2, 4, 4)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%v: expected %q, got %q", tt.mode, tt.want, got)
+		}
+	}
+}
+
+func TestGutter_WidthAdaptsToLineCount(t *testing.T) {
+	g := Gutter{Numbers: NumbersAbsolute}
+	for _, tt := range []struct{ lines, width int }{{5, 3}, {99, 3}, {100, 4}, {12345, 6}} {
+		if got := g.Width(tt.lines); got != tt.width {
+			t.Errorf("Width(%d) = %d, want %d", tt.lines, got, tt.width)
+		}
+		if got := len(ansi.Strip(g.Render(0, 0, tt.lines))); got != tt.width {
+			t.Errorf("rendered width for %d lines = %d, want %d", tt.lines, got, tt.width)
+		}
+	}
+	if got := (Gutter{}).Width(1000); got != 0 {
+		t.Errorf("expected no gutter when off, got width %d", got)
+	}
+}
+
+func TestGutter_Signs(t *testing.T) {
+	errors := SignFunc(func(line int) (Sign, bool) {
+		return Sign{Text: "E"}, line == 1
+	})
+	changes := SignFunc(func(line int) (Sign, bool) {
+		return Sign{Text: "+"}, line <= 1
+	})
+	g := Gutter{Numbers: NumbersAbsolute, Signs: []SignSource{errors, changes}}
+
+	got := gutterColumn(g, 0, 3, 4)
+	want := []string{"+  1 ", "E  2 ", "   3 ", "     "}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if g.Width(3) != 5 {
+		t.Errorf("expected width 5 with signs, got %d", g.Width(3))
+	}
+}
+
+func TestParseLineNumbers(t *testing.T) {
+	for _, mode := range []LineNumbers{NumbersOff, NumbersAbsolute, NumbersRelative, NumbersHybrid} {
+		got, err := ParseLineNumbers(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("ParseLineNumbers(%q) = %v, %v", mode.String(), got, err)
+		}
+	}
+	if _, err := ParseLineNumbers("roman"); err == nil {
+		t.Errorf("expected an error for an unknown mode")
+	}
+	if NumbersHybrid.Next() != NumbersOff {
+		t.Errorf("expected hybrid to cycle back to off")
+	}
+}
+
+func TestRenderBuffer_DrawsGutter(t *testing.T) {
+	out := RenderBuffer(toLines("a", "b"), 0, 5, Viewport{Width: 10, Height: 3}, RenderOptions{
+		Gutter: Gutter{Numbers: NumbersAbsolute},
+	})
+	rows := strings.Split(ansi.Strip(out), "\n")
+	want := []string{" 1 a", " 2 b", "   "}
+	if strings.Join(rows, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, rows)
+	}
+}
@@ -0,0 +1,27 @@
+package ui
+
+import "editGo/syntax"
+
+// runeClasses spreads tokens over the runes of a line n runes long, or
+// returns nil when there are no tokens.
+func runeClasses(tokens []syntax.Token, n int) []syntax.Class {
+	if len(tokens) == 0 {
+		return nil
+	}
+	classes := make([]syntax.Class, n)
+	for _, tok := range tokens {
+		for i := max(tok.Start, 0); i < min(tok.End, n); i++ {
+			classes[i] = tok.Class
+		}
+	}
+	return classes
+}
+
+// styled renders text in the style of class.
+func styled(class syntax.Class, text string) string {
+	style, ok := syntaxStyles[class]
+	if !ok || text == "" {
+		return text
+	}
+	return style.Render(text)
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"editGo/syntax"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+	"testing"
+)
+
+func TestRenderBuffer_Highlights(t *testing.T) {
+	lines := toLines(`func main() { return "x" }`)
+	opts := RenderOptions{Highlighter: syntax.NewHighlighter(syntax.Go{})}
+
+	out := RenderBuffer(lines, 0, 5, Viewport{Width: 80, Height: 1}, opts)
+	for class, text := range map[syntax.Class]string{
+		syntax.Keyword:  "return",
+		syntax.Function: "main",
+		syntax.String:   `"x"`,
+	} {
+		if !strings.Contains(out, syntaxStyles[class].Render(text)) {
+			t.Errorf("expected %q styled as %v in %q", text, class, out)
+		}
+	}
+	if got := ansi.Strip(out); got != string(lines[0]) {
+		t.Errorf("highlighting changed the text: %q", got)
+	}
+}
+
+func TestRenderBuffer_CursorInsideToken(t *testing.T) {
+	lines := toLines(`return`)
+	opts := RenderOptions{Highlighter: syntax.NewHighlighter(syntax.Go{})}
+
+	out := RenderBuffer(lines, 2, 0, Viewport{Width: 80, Height: 1}, opts)
+	want := syntaxStyles[syntax.Keyword].Render("re") + cursorCharStyle.Render("t") + syntaxStyles[syntax.Keyword].Render("urn")
+	if out != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+}
+
+func TestRuneClasses(t *testing.T) {
+	classes := runeClasses([]syntax.Token{{Start: 1, End: 3, Class: syntax.Number}, {Start: 4, End: 9, Class: syntax.Comment}}, 6)
+	want := []syntax.Class{syntax.Plain, syntax.Number, syntax.Number, syntax.Plain, syntax.Comment, syntax.Comment}
+	for i := range want {
+		if classes[i] != want[i] {
+			t.Errorf("rune %d: got %v, want %v", i, classes[i], want[i])
+		}
+	}
+	if runeClasses(nil, 4) != nil {
+		t.Errorf("expected nil classes without tokens")
+	}
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+)
+
+var inputStyle = lipgloss.NewStyle()
+
+// RenderInput draws a one-line text field: prompt, then text with the
+// cursor on rune pos, scrolled sideways to keep the cursor in view.
+func RenderInput(prompt string, text []rune, pos, width int) string {
+	room := max(width-ansi.StringWidth(prompt), 1)
+	pos = min(max(pos, 0), len(text))
+	start := 0
+	for start < pos && ansi.StringWidth(string(text[start:pos]))+1 > room {
+		start++
+	}
+
+	var b strings.Builder
+	b.WriteString(prompt)
+	used := 0
+	for i := start; i <= len(text); i++ {
+		cell := " " // the cursor past the end
+		if i < len(text) {
+			cell = string(text[i])
+		}
+		w := max(ansi.StringWidth(cell), 1)
+		if used+w > room {
+			break
+		}
+		used += w
+		if i == pos {
+			b.WriteString(cursorCharStyle.Render(cell))
+		} else if i < len(text) {
+			b.WriteString(cell)
+		}
+	}
+	return fit(inputStyle, b.String(), width)
+}
+
+// RenderHint shows text, such as completions or help for what is being
+// typed, in the place of the help bar.
+func RenderHint(text string, width int) string {
+	return fit(helpBarStyle, text, width)
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+	"testing"
+)
+
+func TestRenderInput_FillsWidth(t *testing.T) {
+	line := RenderInput(":", []rune("w a.txt"), 7, 30)
+	if got := lipgloss.Width(line); got != 30 {
+		t.Errorf("expected width 30, got %d", got)
+	}
+	if plain := ansi.Strip(line); !strings.HasPrefix(plain, ":w a.txt ") {
+		t.Errorf("unexpected input line %q", plain)
+	}
+}
+
+func TestRenderInput_ScrollsToCursor(t *testing.T) {
+	text := []rune("e " + strings.Repeat("x", 40) + "end")
+	plain := ansi.Strip(RenderInput(":", text, len(text), 20))
+	if !strings.HasSuffix(strings.TrimRight(plain, " "), "end") || lipgloss.Width(plain) != 20 {
+		t.Errorf("expected the end of the text in view, got %q", plain)
+	}
+
+	plain = ansi.Strip(RenderInput(":", text, 0, 20))
+	if !strings.HasPrefix(plain, ":e xxx") {
+		t.Errorf("expected the start of the text in view, got %q", plain)
+	}
+}
+
+func TestRenderInput_DrawsCursor(t *testing.T) {
+	line := RenderInput(":", []rune("set"), 1, 20)
+	if !strings.Contains(line, cursorCharStyle.Render("e")) {
+		t.Errorf("expected the cursor on 'e' in %q", line)
+	}
+	line = RenderInput(":", []rune("set"), 3, 20)
+	if !strings.Contains(line, cursorCharStyle.Render(" ")) {
+		t.Errorf("expected the cursor past the end in %q", line)
+	}
+}
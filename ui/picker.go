@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+)
+
+// FileEntry is one row of the file picker.
+type FileEntry struct {
+	Name string
+	Dir  bool
+}
+
+// FilePicker is what the file picker shows: the directory, the filter
+// typed so far with its cursor, and the entries that match it.
+type FilePicker struct {
+	Dir       string
+	Filter    []rune
+	FilterPos int
+	Entries   []FileEntry
+	Selected  int
+}
+
+// pickerWidth caps how wide the picker grows on big terminals.
+const pickerWidth = 72
+
+// RenderFilePicker draws p as a box in the middle of a width by height
+// area, scrolling the entries to keep the selected one in view.
+func RenderFilePicker(p FilePicker, width, height int) string {
+	inner := max(min(width-4, pickerWidth), 1)
+	rows := max(height-4, 1) // less the border, the directory and the filter
+
+	// keep the head of long paths out of view rather than the tail
+	title := p.Dir
+	if ansi.StringWidth(title) > inner {
+		title = "…" + ansi.TruncateLeft(title, ansi.StringWidth(title)-inner+1, "")
+	}
+	lines := []string{
+		dialogTitleStyle.Render(title),
+		RenderInput("Open: ", p.Filter, p.FilterPos, inner),
+	}
+
+	first := min(max(p.Selected-rows/2, 0), max(len(p.Entries)-rows, 0))
+	for i := first; i < len(p.Entries) && i < first+rows; i++ {
+		entry := p.Entries[i]
+		name, style := entry.Name, lipgloss.NewStyle()
+		if entry.Dir {
+			name += "/"
+			style = dialogTitleStyle
+		}
+		if i == p.Selected {
+			style = undoSelectedStyle
+		}
+		lines = append(lines, fit(style, name, inner))
+	}
+	if len(p.Entries) == 0 {
+		lines = append(lines, undoHintStyle.Render("(no matches)"))
+	}
+	for len(lines) < rows+2 {
+		lines = append(lines, "")
+	}
+
+	box := dialogStyle.Render(strings.Join(lines, "\n"))
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+	"testing"
+)
+
+func TestRenderFilePicker_FillsArea(t *testing.T) {
+	p := FilePicker{
+		Dir:     "/home/me/project",
+		Entries: []FileEntry{{"..", true}, {"cmd", true}, {"main.go", false}},
+	}
+	out := RenderFilePicker(p, 60, 15)
+	if w, h := lipgloss.Size(out); w != 60 || h != 15 {
+		t.Errorf("expected 60x15, got %dx%d", w, h)
+	}
+	plain := ansi.Strip(out)
+	for _, want := range []string{"/home/me/project", "Open:", "cmd/", "main.go"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("expected %q in picker:\n%s", want, plain)
+		}
+	}
+}
+
+func TestRenderFilePicker_ScrollsToSelected(t *testing.T) {
+	var entries []FileEntry
+	for i := range 50 {
+		entries = append(entries, FileEntry{Name: fmt.Sprintf("file%02d.txt", i)})
+	}
+	plain := ansi.Strip(RenderFilePicker(FilePicker{Dir: "/", Entries: entries, Selected: 40}, 60, 14))
+	if !strings.Contains(plain, "file40.txt") || strings.Contains(plain, "file00.txt") {
+		t.Errorf("expected the list scrolled to file40.txt:\n%s", plain)
+	}
+	if _, h := lipgloss.Size(plain); h != 14 {
+		t.Errorf("expected height 14, got %d", h)
+	}
+}
+
+func TestRenderFilePicker_ShortensLongDirectories(t *testing.T) {
+	dir := "/" + strings.Repeat("deep/", 30) + "end"
+	plain := ansi.Strip(RenderFilePicker(FilePicker{Dir: dir}, 40, 10))
+	if !strings.Contains(plain, "…") || !strings.Contains(plain, "deep/end") {
+		t.Errorf("expected the end of the directory kept:\n%s", plain)
+	}
+	if !strings.Contains(plain, "(no matches)") {
+		t.Errorf("expected an empty list to say so:\n%s", plain)
+	}
+}
@@ -1,10 +1,11 @@
 package ui
 
 import (
+	"editGo/editor"
+	"editGo/syntax"
 	"fmt"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/x/term"
-	"os"
+	"github.com/charmbracelet/x/ansi"
 	"strings"
 )
 
@@ -13,48 +14,61 @@ type KeyHelp struct {
 	Action string
 }
 
+// HelpKeys are the keys shown in the help bar, most useful first; the
+// ones that don't fit the width are cut off.
 var HelpKeys = []KeyHelp{
-	{"←/→/↑/↓", "Move"},
-	{"Enter", "New Line"},
-	{"Backspace", "Delete"},
 	{"Ctrl+S", "Save"},
+	{"Ctrl+E", "Command"},
+	{"Ctrl+O", "Open"},
 	{"Ctrl+Z", "Undo"},
 	{"Ctrl+Y", "Redo"},
 	{"Ctrl+Q", "Quit"},
+	{"Ctrl+PgUp/PgDn", "Switch Buffer"},
+	{"Ctrl+W", "Close Buffer"},
+	{"F2", "Undo Tree"},
+	{"F3", "Line Numbers"},
+	{"F4", "Soft Wrap"},
+	{"F5", "Line Endings"},
+	{"F6", "Theme"},
+	{"←/→/↑/↓", "Move"},
+	{"PgUp/PgDn", "Page"},
+	{"Home/End", "Line Start/End"},
+	{"Enter", "New Line"},
+	{"Backspace", "Delete"},
 }
-var statusBarStyle = lipgloss.NewStyle().
-	Background(lipgloss.Color("#44475a")).
-	Foreground(lipgloss.Color("#f8f8f2")).
-	Padding(0, 1)
-
-var helpBarStyle = lipgloss.NewStyle().
-	Background(lipgloss.Color("#282a36")). // slightly different dark shade
-	Foreground(lipgloss.Color("#bd93f9")). // lavender for commands
-	Padding(0, 1).
-	Italic(true)
-
-var cursorCharStyle = lipgloss.NewStyle().
-	Background(lipgloss.Color("#f8f8f2")).
-	Foreground(lipgloss.Color("#282a36")).
-	Bold(true)
-
-var statusMsgStyle = lipgloss.NewStyle().
-	Foreground(lipgloss.Color("#00FF00")). // Green text
-	Background(lipgloss.Color("#1A1A1A")). // Dark gray background
-	Padding(0, 1).
-	Width(100)
-
-func RenderStatusMessage(msg string) string {
+
+// fit renders text in style exactly width cells wide, cutting it short
+// with an ellipsis when it doesn't fit.
+func fit(style lipgloss.Style, text string, width int) string {
+	inner := max(width-style.GetHorizontalFrameSize(), 0)
+	return style.Width(width).Render(ansi.Truncate(text, inner, "…"))
+}
+
+// RenderStatusMessage shows msg in the theme's error or warning colours
+// when it starts with "Error:" or "Warning:", and as information otherwise.
+func RenderStatusMessage(msg string, width int) string {
 	if msg == "" {
 		return ""
 	}
-	return statusMsgStyle.Render("Status: " + msg)
+	style := statusMsgStyle
+	switch {
+	case strings.HasPrefix(msg, "Error:"):
+		style = errorMsgStyle
+	case strings.HasPrefix(msg, "Warning:"):
+		style = warningMsgStyle
+	}
+	return fit(style, "Status: "+msg, width)
 }
-func RenderHelpBar() string {
-	helpText := " Ctrl+S Save | Ctrl+O Open | Ctrl+Z Undo | Ctrl+Y Redo | Ctrl+C Quit "
-	return helpBarStyle.Render(helpText)
+
+// RenderHelpBar lists HelpKeys, as many as fit in width.
+func RenderHelpBar(width int) string {
+	items := make([]string, len(HelpKeys))
+	for i, k := range HelpKeys {
+		items[i] = k.Key + " " + k.Action
+	}
+	return fit(helpBarStyle, " "+strings.Join(items, " | ")+" ", width)
 }
-func RenderStatusBar(filePath string, isDirty bool, cursorX, cursorY int) string {
+func RenderStatusBar(filePath string, isDirty bool, cursorX, cursorY int, fileFormat string, width int) string {
 	dirtyFlag := ""
 	if isDirty {
 		dirtyFlag = "✱"
@@ -64,41 +78,173 @@ func RenderStatusBar(filePath string, isDirty bool, cursorX, cursorY int) string
 		filePath = "[No Name]"
 	}
 
-	status := fmt.Sprintf(" %s %s | Ln %d, Col %d ", filePath, dirtyFlag, cursorY+1, cursorX+1)
-	return statusBarStyle.Render(status)
+	status := fmt.Sprintf(" %s %s | Ln %d, Col %d | %s ", filePath, dirtyFlag, cursorY+1, cursorX+1, fileFormat)
+	return fit(statusBarStyle, status, width)
 }
 
-func RenderBuffer(lines [][]rune, cursorX, cursorY int) string {
-	var out strings.Builder
+// RenderOptions are the display settings RenderBuffer applies.
+type RenderOptions struct {
+	Gutter   Gutter
+	TabWidth int
+	// Wrap folds long lines onto further rows instead of scrolling
+	// sideways; WordWrap breaks them between words where possible.
+	Wrap     bool
+	WordWrap bool
+	// Highlighter colours the text; nil leaves it plain.
+	Highlighter *syntax.Highlighter
+}
+
+// WrapAt returns how lines wrap in a viewport width columns wide.
+func (opts RenderOptions) WrapAt(width int) editor.Wrap {
+	return editor.Wrap{Width: width, TabWidth: opts.TabWidth, Words: opts.WordWrap}
+}
 
-	_, height, err := term.GetSize(os.Stdout.Fd())
-	if err != nil {
-		height = 20 // fallback
+// textLine is a buffer line ready for drawing.
+type textLine struct {
+	runes   []rune
+	classes []syntax.Class // per rune, or nil when not highlighted
+}
+
+func (opts RenderOptions) textLine(lines editor.Lines, y int) textLine {
+	line := lines.GetLine(y)
+	return textLine{
+		runes:   line,
+		classes: runeClasses(opts.Highlighter.Tokens(lines, y), len(line)),
 	}
-	contentHeight := height - 5 // for status and help bar
+}
 
-	for y := 0; y < contentHeight; y++ {
-		var line string
-		if y < len(lines) {
-			runes := lines[y]
+// RenderBuffer draws the part of lines inside the viewport, one row per
+// line of its height; only the lines in view are read. The viewport is in
+// screen columns and only covers the text; the gutter is drawn to its
+// left.
+func RenderBuffer(lines editor.Lines, cursorX, cursorY int, view Viewport, opts RenderOptions) string {
+	if opts.Wrap {
+		return renderWrapped(lines, cursorX, cursorY, view, opts)
+	}
+	count := lines.LineCount()
+	rows := make([]string, 0, view.Height)
+	for y := view.Top; y < view.Top+view.Height; y++ {
+		line := opts.Gutter.Render(y, cursorY, count)
+		if y < count {
+			x := -1
 			if y == cursorY {
-				before := string(runes[:cursorX])
-				cursorChar := " "
-				after := ""
-
-				if cursorX < len(runes) {
-					cursorChar = string(runes[cursorX])
-					after = string(runes[cursorX+1:])
-				}
-				line = before + cursorCharStyle.Render(cursorChar) + after
-			} else {
-				line = string(runes)
+				x = cursorX
 			}
-		} else {
-			line = "" // or "~"
+			text := opts.textLine(lines, y)
+			clusters, end := editor.LayoutColumns(text.runes, view.Left, view.Left+view.Width, opts.TabWidth)
+			line += text.draw(clusters, view.Left, view.Width, x, end)
 		}
-		out.WriteString(line + "\n")
+		rows = append(rows, line)
 	}
 
+	return strings.Join(rows, "\n")
+}
+
+// renderWrapped is RenderBuffer for soft-wrapped lines: the window starts
+// at row view.TopRow of line view.Top and view.Left is ignored.
+func renderWrapped(lines editor.Lines, cursorX, cursorY int, view Viewport, opts RenderOptions) string {
+	wrap := opts.WrapAt(view.Width)
+	count := lines.LineCount()
+	rows := make([]string, 0, view.Height)
+	for y := view.Top; len(rows) < view.Height; y++ {
+		if y >= count {
+			rows = append(rows, opts.Gutter.Render(y, cursorY, count))
+			continue
+		}
+		x := -1
+		if y == cursorY {
+			x = cursorX
+		}
+		text := opts.textLine(lines, y)
+		clusters := editor.Layout(text.runes, opts.TabWidth)
+		segments := wrap.Segments(text.runes)
+		next := 0 // first cluster of the segment
+		for i, seg := range segments {
+			if len(rows) == view.Height {
+				break
+			}
+			first := next
+			for next < len(clusters) && clusters[next].Start < seg.End {
+				next++
+			}
+			if y == view.Top && i < view.TopRow {
+				continue // scrolled off the top
+			}
+			gutter := opts.Gutter.Render(y, cursorY, count)
+			if i > 0 {
+				gutter = opts.Gutter.Blank(count)
+			}
+			from := lineEnd(clusters)
+			if first < len(clusters) {
+				from = clusters[first].Col
+			}
+			end := -1 // the end of the line is on the last row only
+			if i == len(segments)-1 {
+				end = lineEnd(clusters)
+			}
+			rows = append(rows, gutter+text.draw(clusters[first:next], from, view.Width, x, end))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+// lineEnd returns the column just past the last of clusters.
+func lineEnd(clusters []editor.Cluster) int {
+	if len(clusters) == 0 {
+		return 0
+	}
+	last := clusters[len(clusters)-1]
+	return last.Col + last.Width
+}
+
+// draw renders those of clusters that fall in the columns
+// [from, from+width), with the cursor on the character starting at rune
+// cursorX, or in a cell of its own at column end if it is at the end of
+// the line. Tabs become spaces, control characters are shown as ^M and
+// the like, and a wide character cut by either edge is drawn as spaces.
+func (l textLine) draw(clusters []editor.Cluster, from, width, cursorX, end int) string {
+	line := l.runes
+	var out, run strings.Builder
+	runClass := syntax.Plain
+	flush := func() {
+		out.WriteString(styled(runClass, run.String()))
+		run.Reset()
+	}
+	to := from + width
+	for _, c := range clusters {
+		if c.Col < from && c.Col+c.Width <= from {
+			continue
+		}
+		if c.Col >= to {
+			break
+		}
+		text := string(line[c.Start:c.End])
+		switch r := line[c.Start]; {
+		case c.Col < from || c.Col+c.Width > to:
+			text = strings.Repeat(" ", min(c.Col+c.Width, to)-max(c.Col, from))
+		case r == '\t':
+			text = strings.Repeat(" ", c.Width)
+		case editor.IsControl(r):
+			text = "^" + string(r^0x40)
+		}
+		if c.Start == cursorX {
+			flush()
+			out.WriteString(cursorCharStyle.Render(text))
+			continue
+		}
+		class := syntax.Plain
+		if l.classes != nil {
+			class = l.classes[c.Start]
+		}
+		if class != runClass {
+			flush()
+			runClass = class
+		}
+		run.WriteString(text)
+	}
+	flush()
+	if cursorX == len(line) && end >= from && end < to {
+		out.WriteString(cursorCharStyle.Render(" "))
+	}
 	return out.String()
 }
@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain renders styles even without a terminal, so tests can tell
+// where the cursor was drawn.
+func TestMain(m *testing.M) {
+	lipgloss.SetColorProfile(termenv.ANSI)
+	SetTheme(builtinThemes[DefaultTheme])
+	os.Exit(m.Run())
+}
+
+// lineSlice gives a plain slice of lines the editor.Lines methods.
+type lineSlice [][]rune
+
+func (l lineSlice) GetLine(y int) []rune { return l[y] }
+func (l lineSlice) LineCount() int       { return len(l) }
+
+func toLines(text ...string) lineSlice {
+	lines := make(lineSlice, len(text))
+	for i, line := range text {
+		lines[i] = []rune(line)
+	}
+	return lines
+}
+
+func TestRenderStatusBar_StretchesToWidth(t *testing.T) {
+	bar := RenderStatusBar("a.txt", true, 0, 0, "LF", 60)
+	if got := lipgloss.Width(bar); got != 60 {
+		t.Errorf("expected width 60, got %d", got)
+	}
+	if !strings.Contains(bar, "a.txt") {
+		t.Errorf("expected file name in %q", bar)
+	}
+}
+
+func TestRenderStatusBar_TruncatesToWidth(t *testing.T) {
+	bar := RenderStatusBar(strings.Repeat("long/", 30)+"name.txt", false, 4, 9, "CRLF", 40)
+	if strings.Contains(bar, "\n") {
+		t.Fatalf("status bar wrapped: %q", bar)
+	}
+	if got := lipgloss.Width(bar); got != 40 {
+		t.Errorf("expected width 40, got %d", got)
+	}
+	if !strings.Contains(bar, "…") {
+		t.Errorf("expected an ellipsis in %q", bar)
+	}
+}
+
+func TestRenderHelpBarAndMessage_Width(t *testing.T) {
+	for _, width := range []int{10, 80, 200} {
+		if got := lipgloss.Width(RenderHelpBar(width)); got != width {
+			t.Errorf("help bar: expected width %d, got %d", width, got)
+		}
+		msg := RenderStatusMessage("Saved to: /tmp/somewhere/file.txt", width)
+		if got := lipgloss.Width(msg); got != width || strings.Contains(msg, "\n") {
+			t.Errorf("message: expected one row of width %d, got %q", width, msg)
+		}
+	}
+	if RenderStatusMessage("", 80) != "" {
+		t.Errorf("expected no message line for an empty message")
+	}
+}
+
+func TestRenderHelpBar_ListsHelpKeys(t *testing.T) {
+	plain := ansi.Strip(RenderHelpBar(400))
+	for _, k := range HelpKeys {
+		if !strings.Contains(plain, k.Key+" "+k.Action) {
+			t.Errorf("expected %q in %q", k.Key+" "+k.Action, plain)
+		}
+	}
+	narrow := ansi.Strip(RenderHelpBar(40))
+	if !strings.Contains(narrow, "Ctrl+S Save") || !strings.Contains(narrow, "…") {
+		t.Errorf("expected the first keys and an ellipsis in %q", narrow)
+	}
+}
+
+func TestRenderBuffer_ShowsViewport(t *testing.T) {
+	lines := toLines("zero", "one", "two", "three", "four")
+	view := Viewport{Top: 1, Width: 10, Height: 3}
+
+	rows := strings.Split(ansi.Strip(RenderBuffer(lines, 0, 0, view, RenderOptions{})), "\n")
+	want := []string{"one", "two", "three"}
+	if strings.Join(rows, "|") != strings.Join(want, "|") {
+		t.Errorf("expected rows %q, got %q", want, rows)
+	}
+}
+
+// readCounter counts which lines were read.
+type readCounter struct {
+	lineSlice
+	read []int
+}
+
+func (r *readCounter) GetLine(y int) []rune {
+	r.read = append(r.read, y)
+	return r.lineSlice.GetLine(y)
+}
+
+func TestRenderBuffer_ReadsOnlyLinesInView(t *testing.T) {
+	lines := &readCounter{lineSlice: make(lineSlice, 1000)}
+	for _, wrap := range []bool{false, true} {
+		lines.read = nil
+		RenderBuffer(lines, 0, 500, Viewport{Top: 500, Width: 10, Height: 3}, RenderOptions{Wrap: wrap})
+		if fmt.Sprint(lines.read) != "[500 501 502]" {
+			t.Errorf("wrap %v: expected only the lines in view read, got %v", wrap, lines.read)
+		}
+	}
+}
+
+func TestRenderBuffer_PadsShortBuffers(t *testing.T) {
+	out := RenderBuffer(toLines("only"), 0, 0, Viewport{Width: 10, Height: 4}, RenderOptions{})
+	if rows := strings.Count(out, "\n") + 1; rows != 4 {
+		t.Errorf("expected 4 rows, got %d", rows)
+	}
+}
+
+func TestRenderBuffer_ScrollsHorizontally(t *testing.T) {
+	lines := toLines("0123456789abcdef")
+	view := Viewport{Left: 4, Width: 6, Height: 1}
+
+	if got := ansi.Strip(RenderBuffer(lines, 0, 1, view, RenderOptions{})); got != "456789" {
+		t.Errorf("expected %q, got %q", "456789", got)
+	}
+	// the cursor is drawn relative to the window
+	if got := ansi.Strip(RenderBuffer(lines, 6, 0, view, RenderOptions{})); got != "456789" {
+		t.Errorf("expected %q with cursor, got %q", "456789", got)
+	}
+}
+
+func TestRenderBuffer_CursorPastEndOfLine(t *testing.T) {
+	out := RenderBuffer(toLines("ab"), 2, 0, Viewport{Width: 10, Height: 1}, RenderOptions{})
+	if got := ansi.Strip(out); got != "ab " {
+		t.Errorf("expected a cursor cell after the text, got %q", got)
+	}
+	out = RenderBuffer(toLines("ab"), 2, 0, Viewport{Left: 5, Width: 10, Height: 1}, RenderOptions{})
+	if out != "" {
+		t.Errorf("expected no cursor when the end of the line is scrolled off, got %q", out)
+	}
+}
+
+func TestRenderBuffer_SoftWrap(t *testing.T) {
+	lines := toLines("abcdefghij", "xy")
+	opts := RenderOptions{Wrap: true, Gutter: Gutter{Numbers: NumbersAbsolute}}
+
+	out := RenderBuffer(lines, 5, 0, Viewport{Width: 4, Height: 5}, opts)
+	rows := strings.Split(ansi.Strip(out), "\n")
+	want := []string{" 1 abcd", "   efgh", "   ij", " 2 xy", "   "}
+	if strings.Join(rows, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, rows)
+	}
+}
+
+func TestRenderBuffer_SoftWrapStopsAtHeight(t *testing.T) {
+	lines := toLines("aaaaaaaaaaaaaaaaaaaa", "b")
+	out := RenderBuffer(lines, 0, 0, Viewport{Top: 0, Width: 4, Height: 3}, RenderOptions{Wrap: true})
+	if rows := strings.Count(out, "\n") + 1; rows != 3 {
+		t.Errorf("expected 3 rows, got %d", rows)
+	}
+}
+
+func TestRenderBuffer_SoftWrapFromTopRow(t *testing.T) {
+	lines := toLines("abcdefghij", "xy")
+	opts := RenderOptions{Wrap: true, Gutter: Gutter{Numbers: NumbersAbsolute}}
+
+	out := RenderBuffer(lines, 9, 0, Viewport{TopRow: 2, Width: 4, Height: 3}, opts)
+	rows := strings.Split(ansi.Strip(out), "\n")
+	want := []string{"   ij", " 2 xy", "   "}
+	if strings.Join(rows, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, rows)
+	}
+}
+
+func TestRenderBuffer_MixedWidths(t *testing.T) {
+	tests := []struct {
+		line string
+		view Viewport
+		want string
+	}{
+		{"a\tb", Viewport{Width: 10, Height: 1}, "a   b"},
+		{"日本語", Viewport{Width: 10, Height: 1}, "日本語"},
+		{"日本語", Viewport{Left: 1, Width: 4, Height: 1}, " 本 "}, // 日 and 語 cut by the edges
+		{"été", Viewport{Width: 10, Height: 1}, "été"},
+		{"a\rb", Viewport{Width: 10, Height: 1}, "a^Mb"},
+	}
+	for _, tt := range tests {
+		out := RenderBuffer(toLines(tt.line), 0, 5, tt.view, RenderOptions{TabWidth: 4})
+		if got := ansi.Strip(out); got != tt.want {
+			t.Errorf("%q in %+v: expected %q, got %q", tt.line, tt.view, tt.want, got)
+		}
+		if w := lipgloss.Width(out); w > tt.view.Width {
+			t.Errorf("%q: row %d columns wide, viewport %d", tt.line, w, tt.view.Width)
+		}
+	}
+}
+
+func TestRenderBuffer_CursorOnWideCharacter(t *testing.T) {
+	out := RenderBuffer(toLines("a日b"), 1, 0, Viewport{Width: 10, Height: 1}, RenderOptions{})
+	if !strings.Contains(out, cursorCharStyle.Render("日")) {
+		t.Errorf("expected the cursor on 日, got %q", out)
+	}
+	out = RenderBuffer(toLines("a\tb"), 1, 0, Viewport{Width: 10, Height: 1}, RenderOptions{TabWidth: 4})
+	if !strings.Contains(out, cursorCharStyle.Render("   ")) {
+		t.Errorf("expected the cursor to cover the tab, got %q", out)
+	}
+}
+
+func TestRenderBuffer_SoftWrapWideCharacters(t *testing.T) {
+	out := RenderBuffer(toLines("日本語です"), 0, 5, Viewport{Width: 4, Height: 3}, RenderOptions{Wrap: true})
+	rows := strings.Split(ansi.Strip(out), "\n")
+	want := []string{"日本", "語で", "す"}
+	if strings.Join(rows, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, rows)
+	}
+}
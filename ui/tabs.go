@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+)
+
+// Tab is one open buffer in the tab bar.
+type Tab struct {
+	Name  string
+	Dirty bool
+}
+
+// RenderTabBar shows the tabs numbered from 1 with the active one
+// highlighted. When they don't all fit, tabs furthest from the active one
+// are left out and "…" marks the side they were on.
+func RenderTabBar(tabs []Tab, active int, width int) string {
+	labels := make([]string, len(tabs))
+	for i, tab := range tabs {
+		labels[i] = fmt.Sprintf(" %d %s ", i+1, tab.Name)
+		if tab.Dirty {
+			labels[i] = fmt.Sprintf(" %d %s ✱ ", i+1, tab.Name)
+		}
+	}
+	if len(tabs) == 0 {
+		return fit(tabBarStyle, "", width)
+	}
+
+	// Grow the run of shown tabs outwards from the active one, leaving a
+	// column at each end for the markers.
+	first, last := active, active
+	used := ansi.StringWidth(labels[active])
+	for grown := true; grown; {
+		grown = false
+		if last+1 < len(labels) && used+ansi.StringWidth(labels[last+1]) <= width-2 {
+			last++
+			used += ansi.StringWidth(labels[last])
+			grown = true
+		}
+		if first > 0 && used+ansi.StringWidth(labels[first-1]) <= width-2 {
+			first--
+			used += ansi.StringWidth(labels[first])
+			grown = true
+		}
+	}
+
+	var b strings.Builder
+	if first > 0 {
+		b.WriteString(tabBarStyle.Render("…"))
+	}
+	for i := first; i <= last; i++ {
+		style := tabBarStyle
+		if i == active {
+			style = tabActiveStyle
+		}
+		b.WriteString(style.Render(labels[i]))
+	}
+	if last+1 < len(labels) {
+		b.WriteString(tabBarStyle.Render("…"))
+	}
+	return fit(tabBarStyle, b.String(), width)
+}
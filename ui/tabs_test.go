@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"strings"
+	"testing"
+)
+
+func TestRenderTabBar_NumbersTabs(t *testing.T) {
+	tabs := []Tab{{Name: "main.go"}, {Name: "app.go", Dirty: true}}
+	out := RenderTabBar(tabs, 1, 60)
+	if w := lipgloss.Width(out); w != 60 {
+		t.Errorf("expected width 60, got %d", w)
+	}
+	plain := ansi.Strip(out)
+	for _, want := range []string{" 1 main.go ", " 2 app.go ✱ "} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("expected %q in %q", want, plain)
+		}
+	}
+	if !strings.Contains(out, tabActiveStyle.Render(" 2 app.go ✱ ")) {
+		t.Errorf("expected app.go highlighted in %q", out)
+	}
+}
+
+func TestRenderTabBar_KeepsActiveInView(t *testing.T) {
+	var tabs []Tab
+	for i := range 20 {
+		tabs = append(tabs, Tab{Name: fmt.Sprintf("file%02d.go", i)})
+	}
+	plain := ansi.Strip(RenderTabBar(tabs, 15, 50))
+	if !strings.Contains(plain, "16 file15.go") {
+		t.Errorf("expected the active tab shown in %q", plain)
+	}
+	if strings.Contains(plain, "file00.go") || !strings.HasPrefix(plain, "…") {
+		t.Errorf("expected the first tabs left out and marked in %q", plain)
+	}
+	if w := lipgloss.Width(plain); w != 50 {
+		t.Errorf("expected width 50, got %d", w)
+	}
+}
@@ -0,0 +1,365 @@
+package ui
+
+import (
+	"editGo/syntax"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StyleSpec is one themed style. Colours are "#rrggbb" or an ANSI colour
+// number from "0" to "255"; lipgloss converts them to whatever the
+// terminal supports.
+type StyleSpec struct {
+	Fg        string `json:"fg,omitempty" toml:"fg,omitempty"`
+	Bg        string `json:"bg,omitempty" toml:"bg,omitempty"`
+	Bold      bool   `json:"bold,omitempty" toml:"bold,omitempty"`
+	Italic    bool   `json:"italic,omitempty" toml:"italic,omitempty"`
+	Underline bool   `json:"underline,omitempty" toml:"underline,omitempty"`
+	Reverse   bool   `json:"reverse,omitempty" toml:"reverse,omitempty"`
+}
+
+func (s StyleSpec) style() lipgloss.Style {
+	style := lipgloss.NewStyle().
+		Bold(s.Bold).
+		Italic(s.Italic).
+		Underline(s.Underline).
+		Reverse(s.Reverse)
+	if s.Fg != "" {
+		style = style.Foreground(lipgloss.Color(s.Fg))
+	}
+	if s.Bg != "" {
+		style = style.Background(lipgloss.Color(s.Bg))
+	}
+	return style
+}
+
+// Theme holds every colour the UI uses. Theme files are JSON or TOML,
+// named after the theme; fields they leave out come from Base, or the
+// default theme.
+type Theme struct {
+	Name string `json:"-" toml:"-"`
+	Base string `json:"base,omitempty" toml:"base,omitempty"`
+
+	StatusBar     StyleSpec `json:"statusBar" toml:"statusBar"`
+	HelpBar       StyleSpec `json:"helpBar" toml:"helpBar"`
+	TabBar        StyleSpec `json:"tabBar" toml:"tabBar"`
+	TabActive     StyleSpec `json:"tabActive" toml:"tabActive"`
+	Cursor        StyleSpec `json:"cursor" toml:"cursor"`
+	Selection     StyleSpec `json:"selection" toml:"selection"`
+	Gutter        StyleSpec `json:"gutter" toml:"gutter"`
+	GutterCurrent StyleSpec `json:"gutterCurrent" toml:"gutterCurrent"`
+
+	// Message levels for the line under the help bar.
+	Info    StyleSpec `json:"info" toml:"info"`
+	Warning StyleSpec `json:"warning" toml:"warning"`
+	Error   StyleSpec `json:"error" toml:"error"`
+	Prompt  StyleSpec `json:"prompt" toml:"prompt"`
+
+	DiffInsert  StyleSpec `json:"diffInsert" toml:"diffInsert"`
+	DiffDelete  StyleSpec `json:"diffDelete" toml:"diffDelete"`
+	DiffContext StyleSpec `json:"diffContext" toml:"diffContext"`
+
+	Panel         StyleSpec `json:"panel" toml:"panel"` // borders of the undo tree and dialogs
+	PanelSelected StyleSpec `json:"panelSelected" toml:"panelSelected"`
+	PanelHint     StyleSpec `json:"panelHint" toml:"panelHint"`
+
+	// Syntax is keyed by token class name, such as "keyword".
+	Syntax map[string]StyleSpec `json:"syntax" toml:"syntax"`
+}
+
+const DefaultTheme = "dracula"
+
+var builtinThemes = map[string]Theme{
+	"dracula": {
+		Name:          "dracula",
+		StatusBar:     StyleSpec{Fg: "#f8f8f2", Bg: "#44475a"},
+		HelpBar:       StyleSpec{Fg: "#bd93f9", Bg: "#282a36", Italic: true},
+		TabBar:        StyleSpec{Fg: "#6272a4", Bg: "#21222c"},
+		TabActive:     StyleSpec{Fg: "#f8f8f2", Bg: "#44475a", Bold: true},
+		Cursor:        StyleSpec{Fg: "#282a36", Bg: "#f8f8f2", Bold: true},
+		Selection:     StyleSpec{Bg: "#44475a"},
+		Gutter:        StyleSpec{Fg: "#6272a4"},
+		GutterCurrent: StyleSpec{Fg: "#f1fa8c", Bold: true},
+		Info:          StyleSpec{Fg: "#00ff00", Bg: "#1a1a1a"},
+		Warning:       StyleSpec{Fg: "#ffb86c", Bg: "#1a1a1a"},
+		Error:         StyleSpec{Fg: "#ff5555", Bg: "#1a1a1a"},
+		Prompt:        StyleSpec{Fg: "#282a36", Bg: "#ffb86c", Bold: true},
+		DiffInsert:    StyleSpec{Fg: "#50fa7b"},
+		DiffDelete:    StyleSpec{Fg: "#ff5555"},
+		DiffContext:   StyleSpec{Fg: "#6272a4"},
+		Panel:         StyleSpec{Fg: "#bd93f9"},
+		PanelSelected: StyleSpec{Fg: "#f8f8f2", Bg: "#44475a"},
+		PanelHint:     StyleSpec{Fg: "#6272a4", Italic: true},
+		Syntax: map[string]StyleSpec{
+			"keyword":    {Fg: "#ff79c6"},
+			"type":       {Fg: "#8be9fd", Italic: true},
+			"function":   {Fg: "#50fa7b"},
+			"constant":   {Fg: "#bd93f9"},
+			"string":     {Fg: "#f1fa8c"},
+			"number":     {Fg: "#bd93f9"},
+			"comment":    {Fg: "#6272a4"},
+			"operator":   {Fg: "#ff79c6"},
+			"heading":    {Fg: "#bd93f9", Bold: true},
+			"emphasis":   {Fg: "#f1fa8c", Italic: true},
+			"strong":     {Fg: "#ffb86c", Bold: true},
+			"code":       {Fg: "#50fa7b"},
+			"link":       {Fg: "#8be9fd", Underline: true},
+			"quote":      {Fg: "#6272a4", Italic: true},
+			"listMarker": {Fg: "#ff79c6"},
+		},
+	},
+	"light": {
+		Name:          "light",
+		StatusBar:     StyleSpec{Fg: "#1f2328", Bg: "#d0d7de"},
+		HelpBar:       StyleSpec{Fg: "#8250df", Bg: "#f6f8fa", Italic: true},
+		TabBar:        StyleSpec{Fg: "#6e7781", Bg: "#eaeef2"},
+		TabActive:     StyleSpec{Fg: "#1f2328", Bg: "#ffffff", Bold: true},
+		Cursor:        StyleSpec{Fg: "#ffffff", Bg: "#1f2328", Bold: true},
+		Selection:     StyleSpec{Bg: "#ddf4ff"},
+		Gutter:        StyleSpec{Fg: "#8c959f"},
+		GutterCurrent: StyleSpec{Fg: "#1f2328", Bold: true},
+		Info:          StyleSpec{Fg: "#1a7f37", Bg: "#f6f8fa"},
+		Warning:       StyleSpec{Fg: "#9a6700", Bg: "#f6f8fa"},
+		Error:         StyleSpec{Fg: "#cf222e", Bg: "#f6f8fa"},
+		Prompt:        StyleSpec{Fg: "#1f2328", Bg: "#fff8c5", Bold: true},
+		DiffInsert:    StyleSpec{Fg: "#1a7f37"},
+		DiffDelete:    StyleSpec{Fg: "#cf222e"},
+		DiffContext:   StyleSpec{Fg: "#6e7781"},
+		Panel:         StyleSpec{Fg: "#8250df"},
+		PanelSelected: StyleSpec{Fg: "#1f2328", Bg: "#ddf4ff"},
+		PanelHint:     StyleSpec{Fg: "#6e7781", Italic: true},
+		Syntax: map[string]StyleSpec{
+			"keyword":    {Fg: "#cf222e"},
+			"type":       {Fg: "#953800"},
+			"function":   {Fg: "#8250df"},
+			"constant":   {Fg: "#0550ae"},
+			"string":     {Fg: "#0a3069"},
+			"number":     {Fg: "#0550ae"},
+			"comment":    {Fg: "#6e7781", Italic: true},
+			"operator":   {Fg: "#cf222e"},
+			"heading":    {Fg: "#0550ae", Bold: true},
+			"emphasis":   {Italic: true},
+			"strong":     {Bold: true},
+			"code":       {Fg: "#116329"},
+			"link":       {Fg: "#0969da", Underline: true},
+			"quote":      {Fg: "#6e7781", Italic: true},
+			"listMarker": {Fg: "#953800"},
+		},
+	},
+	// basic only uses the 16 standard colours, which terminals let users
+	// pick themselves, so it suits any palette.
+	"basic": {
+		Name:          "basic",
+		StatusBar:     StyleSpec{Reverse: true},
+		HelpBar:       StyleSpec{Fg: "5", Italic: true},
+		TabBar:        StyleSpec{Fg: "8"},
+		TabActive:     StyleSpec{Bold: true, Reverse: true},
+		Cursor:        StyleSpec{Reverse: true},
+		Selection:     StyleSpec{Reverse: true},
+		Gutter:        StyleSpec{Fg: "8"},
+		GutterCurrent: StyleSpec{Fg: "3", Bold: true},
+		Info:          StyleSpec{Fg: "2"},
+		Warning:       StyleSpec{Fg: "3"},
+		Error:         StyleSpec{Fg: "1", Bold: true},
+		Prompt:        StyleSpec{Fg: "0", Bg: "3", Bold: true},
+		DiffInsert:    StyleSpec{Fg: "2"},
+		DiffDelete:    StyleSpec{Fg: "1"},
+		DiffContext:   StyleSpec{Fg: "8"},
+		Panel:         StyleSpec{Fg: "5"},
+		PanelSelected: StyleSpec{Reverse: true},
+		PanelHint:     StyleSpec{Fg: "8", Italic: true},
+		Syntax: map[string]StyleSpec{
+			"keyword":    {Fg: "5"},
+			"type":       {Fg: "6"},
+			"function":   {Fg: "2"},
+			"constant":   {Fg: "5"},
+			"string":     {Fg: "3"},
+			"number":     {Fg: "5"},
+			"comment":    {Fg: "8"},
+			"operator":   {Fg: "5"},
+			"heading":    {Fg: "4", Bold: true},
+			"emphasis":   {Italic: true},
+			"strong":     {Bold: true},
+			"code":       {Fg: "2"},
+			"link":       {Fg: "6", Underline: true},
+			"quote":      {Fg: "8", Italic: true},
+			"listMarker": {Fg: "5"},
+		},
+	},
+}
+
+// Styles built from the current theme by SetTheme.
+var (
+	statusBarStyle      lipgloss.Style
+	helpBarStyle        lipgloss.Style
+	tabBarStyle         lipgloss.Style
+	tabActiveStyle      lipgloss.Style
+	cursorCharStyle     lipgloss.Style
+	selectionStyle      lipgloss.Style
+	gutterStyle         lipgloss.Style
+	gutterCurrentStyle  lipgloss.Style
+	statusMsgStyle      lipgloss.Style
+	warningMsgStyle     lipgloss.Style
+	errorMsgStyle       lipgloss.Style
+	promptStyle         lipgloss.Style
+	diffInsertStyle     lipgloss.Style
+	diffDeleteStyle     lipgloss.Style
+	diffContextStyle    lipgloss.Style
+	undoPanelStyle      lipgloss.Style
+	undoSelectedStyle   lipgloss.Style
+	undoHintStyle       lipgloss.Style
+	dialogStyle         lipgloss.Style
+	dialogTitleStyle    lipgloss.Style
+	dialogButtonStyle   lipgloss.Style
+	dialogSelectedStyle lipgloss.Style
+	syntaxStyles        map[syntax.Class]lipgloss.Style
+
+	currentTheme string
+)
+
+func init() {
+	SetTheme(builtinThemes[DefaultTheme])
+}
+
+// SetTheme restyles the whole UI. On terminals without colour, the cursor
+// and selections fall back to reverse video so they stay visible.
+func SetTheme(t Theme) {
+	noColor := lipgloss.ColorProfile() == termenv.Ascii
+	if noColor {
+		t.Cursor.Reverse = true
+		t.Selection.Reverse = true
+		t.PanelSelected.Reverse = true
+		t.TabActive.Reverse = true
+	}
+
+	statusBarStyle = t.StatusBar.style().Padding(0, 1)
+	helpBarStyle = t.HelpBar.style().Padding(0, 1)
+	tabBarStyle = t.TabBar.style()
+	tabActiveStyle = t.TabActive.style()
+	cursorCharStyle = t.Cursor.style()
+	selectionStyle = t.Selection.style()
+	gutterStyle = t.Gutter.style()
+	gutterCurrentStyle = t.GutterCurrent.style()
+	statusMsgStyle = t.Info.style().Padding(0, 1)
+	warningMsgStyle = t.Warning.style().Padding(0, 1)
+	errorMsgStyle = t.Error.style().Padding(0, 1)
+	promptStyle = t.Prompt.style().Padding(0, 1)
+	diffInsertStyle = t.DiffInsert.style()
+	diffDeleteStyle = t.DiffDelete.style()
+	diffContextStyle = t.DiffContext.style()
+	undoPanelStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(t.Panel.Fg)).
+		Padding(0, 1)
+	undoSelectedStyle = t.PanelSelected.style()
+	undoHintStyle = t.PanelHint.style()
+	dialogStyle = undoPanelStyle
+	dialogTitleStyle = t.Panel.style().Bold(true)
+	dialogButtonStyle = lipgloss.NewStyle().Padding(0, 1)
+	dialogSelectedStyle = t.PanelSelected.style().Padding(0, 1)
+
+	syntaxStyles = map[syntax.Class]lipgloss.Style{}
+	for _, class := range syntax.Classes() {
+		if spec, ok := t.Syntax[class.String()]; ok {
+			syntaxStyles[class] = spec.style()
+		}
+	}
+	currentTheme = t.Name
+}
+
+// CurrentTheme returns the name of the theme in use.
+func CurrentTheme() string {
+	return currentTheme
+}
+
+// themeExts are the theme file formats, in the order FindTheme tries them.
+var themeExts = []string{".json", ".toml"}
+
+// ThemeNames lists the built-in themes and the theme files in dir.
+func ThemeNames(dir string) []string {
+	seen := map[string]bool{}
+	for name := range builtinThemes {
+		seen[name] = true
+	}
+	if dir != "" {
+		for _, ext := range themeExts {
+			files, _ := filepath.Glob(filepath.Join(dir, "*"+ext))
+			for _, file := range files {
+				seen[strings.TrimSuffix(filepath.Base(file), ext)] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FindTheme returns the theme called name: <dir>/<name>.json or
+// <dir>/<name>.toml if there is one, or else the built-in theme.
+func FindTheme(name, dir string) (Theme, error) {
+	if dir != "" {
+		for _, ext := range themeExts {
+			t, err := LoadTheme(filepath.Join(dir, name+ext))
+			if !errors.Is(err, fs.ErrNotExist) {
+				return t, err
+			}
+		}
+	}
+	if t, ok := builtinThemes[name]; ok {
+		return t, nil
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q", name)
+}
+
+// LoadTheme reads a theme file, as TOML if its name ends in .toml and as
+// JSON otherwise.
+func LoadTheme(path string) (Theme, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	var header struct {
+		Base string `json:"base" toml:"base"`
+	}
+	if err := decodeTheme(path, content, &header); err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	if header.Base == "" {
+		header.Base = DefaultTheme
+	}
+	base, ok := builtinThemes[header.Base]
+	if !ok {
+		return Theme{}, fmt.Errorf("theme %s: unknown base theme %q", path, header.Base)
+	}
+
+	t := base
+	t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	t.Syntax = map[string]StyleSpec{}
+	for class, spec := range base.Syntax {
+		t.Syntax[class] = spec
+	}
+	if err := decodeTheme(path, content, &t); err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	return t, nil
+}
+
+func decodeTheme(path string, content []byte, v any) error {
+	if filepath.Ext(path) == ".toml" {
+		_, err := toml.Decode(string(content), v)
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
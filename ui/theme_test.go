@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"editGo/syntax"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeTheme(t *testing.T, dir, file, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, file)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBuiltinThemes_StyleEveryClass(t *testing.T) {
+	for name, theme := range builtinThemes {
+		if theme.Name != name {
+			t.Errorf("theme %q is named %q", name, theme.Name)
+		}
+		for _, class := range syntax.Classes()[1:] {
+			if _, ok := theme.Syntax[class.String()]; !ok {
+				t.Errorf("theme %q has no style for %s", name, class)
+			}
+		}
+	}
+}
+
+func TestLoadTheme_InheritsBase(t *testing.T) {
+	path := writeTheme(t, t.TempDir(), "mine.json", `{
+		"base": "light",
+		"statusBar": {"fg": "#000000", "bg": "#ffffff"},
+		"syntax": {"keyword": {"fg": "#123456", "bold": true}}
+	}`)
+	theme, err := LoadTheme(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	light := builtinThemes["light"]
+	if theme.Name != "mine" {
+		t.Errorf("expected name from file, got %q", theme.Name)
+	}
+	if theme.StatusBar.Bg != "#ffffff" || theme.HelpBar != light.HelpBar {
+		t.Errorf("expected overrides on top of light, got %+v", theme)
+	}
+	if theme.Syntax["keyword"].Fg != "#123456" || theme.Syntax["string"] != light.Syntax["string"] {
+		t.Errorf("unexpected syntax styles %+v", theme.Syntax)
+	}
+	if light.Syntax["keyword"].Fg == "#123456" {
+		t.Errorf("loading a theme changed the built-in one")
+	}
+}
+
+func TestLoadTheme_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadTheme(writeTheme(t, dir, "bad.json", `{"base": "nope"}`)); err == nil {
+		t.Errorf("expected an error for an unknown base")
+	}
+	if _, err := LoadTheme(writeTheme(t, dir, "broken.json", `{"cursor": 1}`)); err == nil {
+		t.Errorf("expected an error for a malformed theme")
+	}
+}
+
+func TestLoadTheme_TOML(t *testing.T) {
+	path := writeTheme(t, t.TempDir(), "forest.toml", `
+base = "basic"
+
+[statusBar]
+fg = "#ffffff"
+bg = "#004400"
+bold = true
+
+[syntax.keyword]
+fg = "2"
+`)
+	theme, err := LoadTheme(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	basic := builtinThemes["basic"]
+	if theme.Name != "forest" {
+		t.Errorf("expected name from file, got %q", theme.Name)
+	}
+	if theme.StatusBar.Bg != "#004400" || !theme.StatusBar.Bold || theme.HelpBar != basic.HelpBar {
+		t.Errorf("expected overrides on top of basic, got %+v", theme)
+	}
+	if theme.Syntax["keyword"].Fg != "2" || theme.Syntax["string"] != basic.Syntax["string"] {
+		t.Errorf("unexpected syntax styles %+v", theme.Syntax)
+	}
+	if _, err := LoadTheme(writeTheme(t, t.TempDir(), "broken.toml", `cursor = 1`)); err == nil {
+		t.Errorf("expected an error for a malformed theme")
+	}
+}
+
+func TestFindTheme_PrefersUserThemes(t *testing.T) {
+	dir := t.TempDir()
+	writeTheme(t, dir, "light.json", `{"gutter": {"fg": "1"}}`)
+	writeTheme(t, dir, "ocean.json", `{}`)
+	writeTheme(t, dir, "forest.toml", `[gutter]
+fg = "2"`)
+
+	theme, err := FindTheme("light", dir)
+	if err != nil || theme.Gutter.Fg != "1" {
+		t.Errorf("expected the user's light theme, got %+v, %v", theme.Gutter, err)
+	}
+	if theme, err := FindTheme("basic", dir); err != nil || theme.Name != "basic" {
+		t.Errorf("expected the built-in basic theme, got %q, %v", theme.Name, err)
+	}
+	if _, err := FindTheme("missing", dir); err == nil {
+		t.Errorf("expected an error for an unknown theme")
+	}
+
+	if theme, err := FindTheme("forest", dir); err != nil || theme.Gutter.Fg != "2" {
+		t.Errorf("expected the user's TOML theme, got %+v, %v", theme.Gutter, err)
+	}
+
+	want := []string{"basic", "dracula", "forest", "light", "ocean"}
+	if got := ThemeNames(dir); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSetTheme_Restyles(t *testing.T) {
+	t.Cleanup(func() { SetTheme(builtinThemes[DefaultTheme]) })
+
+	dark := RenderStatusBar("a.txt", false, 0, 0, "LF", 40)
+	SetTheme(builtinThemes["light"])
+	if CurrentTheme() != "light" {
+		t.Errorf("expected light, got %q", CurrentTheme())
+	}
+	if light := RenderStatusBar("a.txt", false, 0, 0, "LF", 40); light == dark {
+		t.Errorf("status bar did not change with the theme")
+	}
+	if got := syntaxStyles[syntax.Keyword].GetForeground(); got != lipgloss.Color("#cf222e") {
+		t.Errorf("expected light keyword colour, got %v", got)
+	}
+}
+
+func TestSetTheme_NoColorFallsBackToReverse(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	t.Cleanup(func() {
+		lipgloss.SetColorProfile(termenv.ANSI)
+		SetTheme(builtinThemes[DefaultTheme])
+	})
+
+	SetTheme(builtinThemes[DefaultTheme])
+	if !cursorCharStyle.GetReverse() || !undoSelectedStyle.GetReverse() {
+		t.Errorf("expected the cursor and selection in reverse video")
+	}
+}
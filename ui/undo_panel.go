@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"editGo/editor"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RenderUndoTree lists the branches of the undo tree, newest first, with
+// the selected row highlighted and the branch Redo would follow marked.
+// It is at most height rows tall, scrolling the list to keep the
+// selected branch visible.
+func RenderUndoTree(branches []editor.UndoBranch, currentSeq, selected int, now time.Time, height int) string {
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("Undo tree (state #%d)\n", currentSeq))
+
+	// rows left once the border, title and hint are drawn
+	rows := max(height-4, 1)
+	newest := len(branches) - 1
+	first := min(newest, max(selected+rows/2, rows-1)) // first row listed
+	for i := first; i >= 0 && i > first-rows; i-- {
+		b := branches[i]
+		marker := " "
+		if b.Active {
+			marker = "●"
+		}
+		row := fmt.Sprintf("%s #%-4d %3d steps  %s (%s)",
+			marker, b.Seq, b.Depth, b.Time.Format("15:04:05"), formatAge(now.Sub(b.Time)))
+		if i == selected {
+			row = undoSelectedStyle.Render(row)
+		}
+		out.WriteString(row + "\n")
+	}
+
+	out.WriteString(undoHintStyle.Render("↑/↓ select  Enter jump  -/+ step  </> ±1m  Esc close"))
+	return undoPanelStyle.Render(out.String())
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"editGo/editor"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderUndoTree_ScrollsToSelected(t *testing.T) {
+	now := time.Now()
+	var branches []editor.UndoBranch
+	for i := 0; i < 40; i++ {
+		branches = append(branches, editor.UndoBranch{Seq: i + 1, Time: now, Depth: i})
+	}
+
+	out := RenderUndoTree(branches, 1, 0, now, 12)
+	if rows := strings.Count(out, "\n") + 1; rows > 12 {
+		t.Errorf("expected at most 12 rows, got %d", rows)
+	}
+	if !strings.Contains(out, fmt.Sprintf("#%-4d", 1)) {
+		t.Errorf("selected oldest branch not shown:\n%s", out)
+	}
+	if strings.Contains(out, fmt.Sprintf("#%-4d", 40)) {
+		t.Errorf("newest branch should be scrolled out of view:\n%s", out)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		5 * time.Second: "5s ago",
+		3 * time.Minute: "3m ago",
+		2 * time.Hour:   "2h ago",
+		50 * time.Hour:  "2d ago",
+	}
+	for d, want := range tests {
+		if got := formatAge(d); got != want {
+			t.Errorf("formatAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package ui
+
+// Viewport is the window of the buffer shown on screen: Top is the first
+// visible line and Left the first visible column. With soft wrap, TopRow
+// is the first visible display row of Top, so a line taller than the
+// window can still be scrolled through.
+type Viewport struct {
+	Top    int
+	TopRow int
+	Left   int
+	Width  int
+	Height int
+
+	// ScrollOff is how many lines stay visible above and below the
+	// cursor, and SideScrollOff how many columns to its left and right.
+	ScrollOff     int
+	SideScrollOff int
+}
+
+func NewViewport(width, height int) *Viewport {
+	v := &Viewport{}
+	v.Resize(width, height)
+	return v
+}
+
+// Resize changes the window size; the next Follow keeps the cursor in it.
+func (v *Viewport) Resize(width, height int) {
+	v.Width = max(width, 1)
+	v.Height = max(height, 1)
+}
+
+// Follow scrolls just enough to show line y, column x with the scroll-off
+// margins around it. Margins shrink on small windows so the cursor can
+// still reach every row.
+func (v *Viewport) Follow(x, y int) {
+	v.Top = follow(v.Top, y, v.Height, v.ScrollOff)
+	v.TopRow = 0
+	v.Left = follow(v.Left, x, v.Width, v.SideScrollOff)
+}
+
+// FollowWrapped is Follow for soft-wrapped text, where the window and its
+// margins move by display rows: rows(line) is how many rows a line takes,
+// and the cursor is on row cursorRow of line y.
+func (v *Viewport) FollowWrapped(y, cursorRow int, rows func(line int) int) {
+	v.Left = 0
+	margin := min(v.ScrollOff, (v.Height-1)/2)
+	v.TopRow = min(v.TopRow, rows(v.Top)-1) // the line may have shrunk
+	if y < v.Top || (y == v.Top && cursorRow < v.TopRow) {
+		v.Top, v.TopRow = y, cursorRow
+	}
+	above := cursorRow - v.TopRow // rows between the top and the cursor
+	for line := v.Top; line < y; line++ {
+		above += rows(line)
+	}
+	for above < margin && (v.Top > 0 || v.TopRow > 0) {
+		if v.TopRow == 0 {
+			v.Top--
+			v.TopRow = rows(v.Top)
+		}
+		n := min(v.TopRow, margin-above)
+		v.TopRow -= n
+		above += n
+	}
+	for limit := v.Height - 1 - margin; above > limit; {
+		shown := rows(v.Top) - v.TopRow // rows of Top in the window
+		if above-shown < limit {
+			v.TopRow += above - limit
+			break
+		}
+		above -= shown
+		v.Top++
+		v.TopRow = 0
+	}
+}
+
+// Scroll moves the window n lines down, or up for negative n, without
+// going past either end of a buffer with lineCount lines.
+func (v *Viewport) Scroll(n, lineCount int) {
+	v.Top = min(v.Top+n, lineCount-v.Height)
+	v.Top = max(v.Top, 0)
+	v.TopRow = 0
+}
+
+// ScrollWrapped is Scroll for soft-wrapped text, moving the window n
+// display rows; rows(line) is how many rows a line takes.
+func (v *Viewport) ScrollWrapped(n, lineCount int, rows func(line int) int) {
+	v.Left = 0
+	if lineCount == 0 {
+		return
+	}
+	for ; n > 0 && v.Top < lineCount; n-- {
+		if v.TopRow++; v.TopRow >= rows(v.Top) {
+			v.Top++
+			v.TopRow = 0
+		}
+	}
+	for ; n < 0 && (v.Top > 0 || v.TopRow > 0); n++ {
+		if v.TopRow--; v.TopRow < 0 {
+			v.Top--
+			v.TopRow = rows(v.Top) - 1
+		}
+	}
+
+	// Stop at the last page, whose bottom row is the last row of the text.
+	last, lastRow := lineCount-1, rows(lineCount-1)-1
+	for up := v.Height - 1; up > 0 && (last > 0 || lastRow > 0); up-- {
+		if lastRow--; lastRow < 0 {
+			last--
+			lastRow = rows(last) - 1
+		}
+	}
+	if v.Top > last || (v.Top == last && v.TopRow > lastRow) {
+		v.Top, v.TopRow = last, lastRow
+	}
+}
+
+func follow(start, pos, size, margin int) int {
+	margin = min(margin, (size-1)/2)
+	if pos < start+margin {
+		start = pos - margin
+	}
+	if pos > start+size-1-margin {
+		start = pos - size + 1 + margin
+	}
+	return max(start, 0)
+}
@@ -0,0 +1,162 @@
+package ui
+
+import "testing"
+
+func TestViewport_FollowKeepsMargin(t *testing.T) {
+	v := NewViewport(20, 10)
+	v.ScrollOff = 2
+
+	v.Follow(0, 7)
+	if v.Top != 0 {
+		t.Errorf("cursor inside the margin-free area should not scroll, top %d", v.Top)
+	}
+	v.Follow(0, 8)
+	if v.Top != 1 {
+		t.Errorf("expected top 1 to keep 2 lines below the cursor, got %d", v.Top)
+	}
+	v.Follow(0, 50)
+	if v.Top != 43 {
+		t.Errorf("expected top 43, got %d", v.Top)
+	}
+	v.Follow(0, 44)
+	if v.Top != 42 {
+		t.Errorf("expected top 42 to keep 2 lines above the cursor, got %d", v.Top)
+	}
+	v.Follow(0, 0)
+	if v.Top != 0 {
+		t.Errorf("expected top 0, got %d", v.Top)
+	}
+}
+
+func TestViewport_FollowHorizontal(t *testing.T) {
+	v := NewViewport(10, 5)
+	v.SideScrollOff = 3
+
+	v.Follow(12, 0)
+	if v.Left != 6 {
+		t.Errorf("expected left 6, got %d", v.Left)
+	}
+	v.Follow(7, 0)
+	if v.Left != 4 {
+		t.Errorf("expected left 4, got %d", v.Left)
+	}
+}
+
+func TestViewport_MarginShrinksOnSmallWindow(t *testing.T) {
+	v := NewViewport(10, 3)
+	v.ScrollOff = 5
+
+	for y := 0; y < 10; y++ {
+		v.Follow(0, y)
+		if y < v.Top || y >= v.Top+v.Height {
+			t.Fatalf("cursor line %d off screen, top %d", y, v.Top)
+		}
+	}
+}
+
+func TestViewport_Scroll(t *testing.T) {
+	v := NewViewport(10, 5)
+
+	v.Scroll(4, 20)
+	if v.Top != 4 {
+		t.Errorf("expected top 4, got %d", v.Top)
+	}
+	v.Scroll(100, 20)
+	if v.Top != 15 {
+		t.Errorf("expected scrolling to stop at the last page, got %d", v.Top)
+	}
+	v.Scroll(-100, 20)
+	if v.Top != 0 {
+		t.Errorf("expected top 0, got %d", v.Top)
+	}
+	v.Scroll(3, 2)
+	if v.Top != 0 {
+		t.Errorf("short buffers should not scroll, got %d", v.Top)
+	}
+}
+
+func TestViewport_FollowWrapped(t *testing.T) {
+	// every line takes two display rows
+	rows := func(int) int { return 2 }
+	v := NewViewport(10, 6)
+	v.ScrollOff = 1
+
+	v.FollowWrapped(2, 0, rows)
+	if v.Top != 0 {
+		t.Errorf("cursor on row 4 of 6 should not scroll, top %d", v.Top)
+	}
+	v.FollowWrapped(2, 1, rows)
+	if v.Top != 0 || v.TopRow != 1 {
+		t.Errorf("expected one row scrolled to keep a row below the cursor, got %d.%d", v.Top, v.TopRow)
+	}
+	v.FollowWrapped(0, 1, rows)
+	if v.Top != 0 || v.TopRow != 0 {
+		t.Errorf("expected top 0.0 to keep a row above the cursor, got %d.%d", v.Top, v.TopRow)
+	}
+	v.Left = 7
+	v.FollowWrapped(10, 1, rows)
+	if v.Top != 8 || v.TopRow != 1 || v.Left != 0 {
+		t.Errorf("expected top 8.1 and no side scroll, got %d.%d, %d", v.Top, v.TopRow, v.Left)
+	}
+}
+
+func TestViewport_FollowWrappedTallLine(t *testing.T) {
+	// line 1 wraps to 10 rows, more than the window holds
+	rows := func(line int) int {
+		if line == 1 {
+			return 10
+		}
+		return 1
+	}
+	v := NewViewport(10, 4)
+	v.ScrollOff = 1
+
+	for row := range 10 {
+		v.FollowWrapped(1, row, rows)
+		above := row - v.TopRow
+		if v.Top == 0 {
+			above = row + 1 - v.TopRow
+		}
+		if above < 0 || above >= v.Height {
+			t.Fatalf("cursor on row %d off screen, top %d.%d", row, v.Top, v.TopRow)
+		}
+	}
+	if v.Top != 1 || v.TopRow != 7 {
+		t.Errorf("expected top 1.7 with the last row in view, got %d.%d", v.Top, v.TopRow)
+	}
+	v.FollowWrapped(2, 0, rows)
+	if v.Top != 1 || v.TopRow != 8 {
+		t.Errorf("expected top 1.8, got %d.%d", v.Top, v.TopRow)
+	}
+	v.FollowWrapped(1, 2, rows)
+	if v.Top != 1 || v.TopRow != 1 {
+		t.Errorf("expected top 1.1 to keep a row above the cursor, got %d.%d", v.Top, v.TopRow)
+	}
+}
+
+func TestViewport_ScrollWrapped(t *testing.T) {
+	// 5 lines of 3 rows each, 15 rows in all
+	rows := func(int) int { return 3 }
+	v := NewViewport(10, 4)
+
+	v.ScrollWrapped(4, 5, rows)
+	if v.Top != 1 || v.TopRow != 1 {
+		t.Errorf("expected top 1.1, got %d.%d", v.Top, v.TopRow)
+	}
+	v.ScrollWrapped(100, 5, rows)
+	if v.Top != 3 || v.TopRow != 2 {
+		t.Errorf("expected scrolling to stop at the last page, got %d.%d", v.Top, v.TopRow)
+	}
+	v.ScrollWrapped(-5, 5, rows)
+	if v.Top != 2 || v.TopRow != 0 {
+		t.Errorf("expected top 2.0, got %d.%d", v.Top, v.TopRow)
+	}
+	v.ScrollWrapped(-100, 5, rows)
+	if v.Top != 0 || v.TopRow != 0 {
+		t.Errorf("expected top 0.0, got %d.%d", v.Top, v.TopRow)
+	}
+	v.ScrollWrapped(3, 1, rows)
+	if v.Top != 0 || v.TopRow != 0 {
+		t.Errorf("text shorter than the window should not scroll, got %d.%d", v.Top, v.TopRow)
+	}
+}